@@ -0,0 +1,12 @@
+#!/bin/bash
+# 產生 docker-compose-local.yml 使用的 secrets，已經存在的檔案不會被覆蓋。
+# secrets/ 不進版控，每個開發者在第一次 docker compose up 之前執行一次
+set -eu
+cd "$(dirname "$0")"
+mkdir -p secrets
+for name in db_password jwt_secret; do
+  if [ ! -e "secrets/$name" ]; then
+    (umask 077 && openssl rand -hex 32 | tr -d '\n' > "secrets/$name")
+    echo "created secrets/$name"
+  fi
+done
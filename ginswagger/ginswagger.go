@@ -0,0 +1,52 @@
+// Package ginswagger 在 gin router 上提供 /openapi.json 與內嵌的 Swagger UI，
+// go-gin-YT 與 go-gin-gorm-protobuf 各自產生文件後都用它對外提供
+package ginswagger
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// SpecPath 是 OpenAPI 文件的位置，DocsPath 是讀取它的 Swagger UI
+const (
+	SpecPath = "/openapi.json"
+	DocsPath = "/docs"
+)
+
+// swaggerInitializer 取代 swagger-ui 內建的設定，預設會載入 petstore 的範例
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "` + SpecPath + `",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// Register 提供 GET /openapi.json 與 GET /docs/ (Swagger UI)，文件只在啟動時序列化一次
+func Register(router gin.IRoutes, doc *openapi3.T) error {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	router.GET(SpecPath, func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", spec)
+	})
+
+	files := http.StripPrefix(DocsPath, http.FileServer(http.FS(swaggerFiles.FS)))
+	router.GET(DocsPath+"/*filepath", func(c *gin.Context) {
+		if c.Param("filepath") == "/swagger-initializer.js" {
+			c.Data(http.StatusOK, "text/javascript; charset=utf-8", []byte(swaggerInitializer))
+			return
+		}
+		files.ServeHTTP(c.Writer, c.Request)
+	})
+	return nil
+}
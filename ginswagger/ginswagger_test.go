@@ -0,0 +1,59 @@
+package ginswagger_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ginswagger"
+	"ginswagger/swaggertest"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+func newRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users/:id", func(*gin.Context) {})
+
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info:    &openapi3.Info{Title: "test", Version: "1.0.0"},
+		Paths:   openapi3.NewPaths(),
+	}
+	op := openapi3.NewOperation()
+	op.AddResponse(http.StatusOK, openapi3.NewResponse().WithDescription("OK"))
+	op.AddParameter(openapi3.NewPathParameter("id").WithSchema(openapi3.NewIntegerSchema()))
+	doc.AddOperation("/users/{id}", http.MethodGet, op)
+	if err := ginswagger.Register(router, doc); err != nil {
+		t.Fatal(err)
+	}
+	return router
+}
+
+func TestRegister(t *testing.T) {
+	router := newRouter(t)
+	doc := swaggertest.Spec(t, router)
+	if doc.Info.Title != "test" {
+		t.Errorf("info = %+v", doc.Info)
+	}
+	// /openapi.json 與 /docs 本身不需要寫進文件
+	swaggertest.Documented(t, router.Routes(), doc)
+}
+
+func TestSwaggerUI(t *testing.T) {
+	router := newRouter(t)
+	for path, want := range map[string]string{
+		"/docs/":                       "swagger-ui",
+		"/docs/swagger-initializer.js": `url: "/openapi.json"`,
+		"/docs/swagger-ui-bundle.js":   "SwaggerUIBundle",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("GET %s: %d, want body containing %q", path, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package swaggertest 是測試用的 helper，檢查 ginswagger.Register 提供的文件
+package swaggertest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"ginswagger"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+var ginParam = regexp.MustCompile(`:([a-z_]+)`)
+
+// Spec 讀取 handler 的 /openapi.json，文件必須通過 OpenAPI 的驗證
+func Spec(t testing.TB, handler http.Handler) *openapi3.T {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", ginswagger.SpecPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: %d", ginswagger.SpecPath, rec.Code)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(rec.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	return doc
+}
+
+// Documented 檢查每個 gin 路由都出現在 doc 裡，新增路由卻沒有寫進文件時會失敗
+func Documented(t testing.TB, routes gin.RoutesInfo, doc *openapi3.T) {
+	t.Helper()
+	for _, route := range routes {
+		if route.Path == ginswagger.SpecPath || strings.HasPrefix(route.Path, ginswagger.DocsPath+"/") {
+			continue
+		}
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		if item := doc.Paths.Find(path); item == nil || item.GetOperation(route.Method) == nil {
+			t.Errorf("%s %s is not documented", route.Method, path)
+		}
+	}
+}
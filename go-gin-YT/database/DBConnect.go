@@ -1,18 +1,103 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go-gin-YT/pojo"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"log"
 )
 
+// DB 在 DBConnect 成功之後才會設定，使用前先確認 Ready
 var DB *gorm.DB
 
-func DBConnect() {
-	dsn := "host=localhost user=root password=Root&123 dbname=go port=5432 sslmode=disable"
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+var ready atomic.Bool
+
+// ErrNotConnected 代表還沒有連上資料庫
+var ErrNotConnected = errors.New("database is not connected")
+
+const defaultDSN = "host=localhost user=root password=Root&123 dbname=go port=5432 sslmode=disable"
+
+// 重試的間隔從 initialBackoff 開始每次加倍，最多 maxBackoff
+const (
+	initialBackoff = 200 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+)
+
+// DSN 取自環境變數 DATABASE_DSN，沒有設定時使用本機開發的預設值
+func DSN() string {
+	if dsn := os.Getenv("DATABASE_DSN"); dsn != "" {
+		return dsn
+	}
+	return defaultDSN
+}
+
+// DBConnect 連線並建立資料表，失敗時以指數退避重試，直到成功或 ctx 結束 (啟動期限)
+func DBConnect(ctx context.Context, dsn string) error {
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		db, err := open(ctx, dsn)
+		if err == nil {
+			DB = db
+			ready.Store(true)
+			return nil
+		}
+		log.Printf("Failed to connect to database (attempt %d), retrying in %v: %v", attempt, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("connect to database: %w (last error: %v)", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		backoff = nextBackoff(backoff)
+	}
+}
+
+// open 連線、ping 並建立資料表，都受 ctx 的期限限制；失敗時關閉這次開啟的連線池，重試不會累積連線
+func open(ctx context.Context, dsn string) (*gorm.DB, error) {
+	// gorm.Open 內建的 ping 不吃 ctx，改成自己用 ctx ping
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	if err := db.WithContext(ctx).AutoMigrate(&pojo.User{}); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("migrate users table: %w", err)
+	}
+	return db, nil
+}
+
+func nextBackoff(d time.Duration) time.Duration {
+	return min(2*d, maxBackoff)
+}
+
+// Ready 回傳 DBConnect 是否已經成功
+func Ready() bool {
+	return ready.Load()
+}
+
+// Ping 確認資料庫現在連得上，給 /readyz 使用
+func Ping(ctx context.Context) error {
+	if !Ready() {
+		return ErrNotConnected
+	}
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatal("Failed to connect to database", err)
+		return err
 	}
+	return sqlDB.PingContext(ctx)
 }
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNextBackoff(t *testing.T) {
+	d := initialBackoff
+	var got []time.Duration
+	for i := 0; i < 7; i++ {
+		got = append(got, d)
+		d = nextBackoff(d)
+	}
+	want := []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond,
+		1600 * time.Millisecond, 3200 * time.Millisecond, maxBackoff, maxBackoff}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("backoff sequence = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDBConnectGivesUpAtDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	// port 1 沒有服務，每次連線都會立刻被拒絕
+	err := DBConnect(ctx, "host=127.0.0.1 port=1 user=root dbname=go sslmode=disable connect_timeout=1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DBConnect = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("DBConnect returned after %v, past the startup deadline", elapsed)
+	}
+	if Ready() || !errors.Is(Ping(context.Background()), ErrNotConnected) {
+		t.Error("database reported ready after a failed connect")
+	}
+}
+
+func TestDBConnectHungServer(t *testing.T) {
+	// 接受連線但從不回應，沒有 connect_timeout 時只能靠 ctx 結束這次嘗試
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		var conns []net.Conn
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				break
+			}
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	port := ln.Addr().(*net.TCPAddr).Port
+	err = DBConnect(ctx, fmt.Sprintf("host=127.0.0.1 port=%d user=root dbname=go sslmode=disable", port))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DBConnect = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("DBConnect returned after %v, a hung attempt ignored the startup deadline", elapsed)
+	}
+}
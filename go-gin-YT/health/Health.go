@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status 是 /healthz 與 /readyz 的回應
+type Status struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Healthz 是 liveness probe，只要程序還能處理請求就回 200
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, Status{Status: "ok"})
+}
+
+// Readyz 是 readiness probe，check (例如資料庫 ping) 失敗時回 503，讓負載平衡器暫時不要送流量過來
+func Readyz(check func(ctx context.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Second)
+		defer cancel()
+		if err := check(ctx); err != nil {
+			c.JSON(http.StatusServiceUnavailable, Status{Status: "unavailable", Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, Status{Status: "ok"})
+	}
+}
+
+// ReadinessGate 在 ready 回傳 false 之前 (例如還沒連上資料庫) 直接回應 503
+func ReadinessGate(ready func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !ready() {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "service is starting, try again later"})
+			return
+		}
+		c.Next()
+	}
+}
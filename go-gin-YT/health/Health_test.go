@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var ready atomic.Bool
+	check := func(context.Context) error {
+		if !ready.Load() {
+			return errors.New("database is not connected")
+		}
+		return nil
+	}
+
+	router := gin.New()
+	router.GET("/healthz", Healthz)
+	router.GET("/readyz", Readyz(check))
+	router.GET("/v1/users/", ReadinessGate(ready.Load), func(c *gin.Context) { c.JSON(http.StatusOK, "users") })
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	for _, tt := range []struct {
+		ready bool
+		path  string
+		want  int
+	}{
+		{false, "/healthz", http.StatusOK},
+		{false, "/readyz", http.StatusServiceUnavailable},
+		{false, "/v1/users/", http.StatusServiceUnavailable},
+		{true, "/healthz", http.StatusOK},
+		{true, "/readyz", http.StatusOK},
+		{true, "/v1/users/", http.StatusOK},
+	} {
+		ready.Store(tt.ready)
+		if w := get(tt.path); w.Code != tt.want {
+			t.Errorf("ready=%v GET %s = %d, want %d", tt.ready, tt.path, w.Code, tt.want)
+		}
+	}
+
+	ready.Store(false)
+	if w := get("/v1/users/"); w.Header().Get("Retry-After") == "" {
+		t.Error("503 from the readiness gate has no Retry-After")
+	}
+}
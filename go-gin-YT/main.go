@@ -1,19 +1,52 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"ginswagger"
 	"github.com/gin-gonic/gin"
 	"go-gin-YT/database"
+	"go-gin-YT/health"
+	"go-gin-YT/openapi"
+	"go-gin-YT/repository"
+	"go-gin-YT/service"
 	. "go-gin-YT/src"
 )
 
 func main() {
-	router := gin.Default()
-	v1 := router.Group("/v1")
-	AddUserRouter(v1)
+	// 不想啟動 Postgres 時可以用 -store memory
+	store := flag.String("store", "postgres", "user store: postgres or memory")
+	startupTimeout := flag.Duration("db-startup-timeout", 30*time.Second, "give up if the database is not reachable within this time")
+	flag.Parse()
+
+	users, err := repository.New(*store)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ready := func() bool { return true }
+	check := func(context.Context) error { return nil }
+	if *store != "memory" {
+		ready, check = database.Ready, database.Ping
+		// 先開始接受請求讓 /healthz 可以回應，連上資料庫之前 /v1、/v2 都回 503
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), *startupTimeout)
+			defer cancel()
+			if err := database.DBConnect(ctx, database.DSN()); err != nil {
+				log.Fatal(err)
+			}
+			log.Println("Connected to database")
+		}()
+	}
+
+	router, err := newRouter(users, ready, check)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	go func() {
-		database.DBConnect()
-	}()
 	//router.GET("/ping", func(c *gin.Context) {
 	//	c.JSON(http.StatusOK, gin.H{
 	//		"message": "ping",
@@ -21,3 +54,32 @@ func main() {
 	//})
 	router.Run(":8000")
 }
+
+// newRouter 註冊所有路由，/openapi.json 的內容與這裡的路由一一對應
+func newRouter(users repository.UserRepository, ready func() bool, check func(context.Context) error) (*gin.Engine, error) {
+	router := gin.Default()
+	router.GET("/healthz", health.Healthz)
+	router.GET("/readyz", health.Readyz(check))
+
+	// /v1 沿用原本的 JSON 欄位名稱，/v2 使用 snake_case，兩個版本共用同一份資料
+	gate := health.ReadinessGate(ready)
+	v1 := &service.UserService{Users: users, Version: service.V1}
+	v2 := &service.UserService{Users: users, Version: service.V2}
+	AddUserRouter(router.Group("/v1", gate), v1)
+	AddUserRouter(router.Group("/v2", gate), v2)
+
+	doc := openapi.New("go-gin-YT users", "2.0.0")
+	if err := AddHealthDocs(doc); err != nil {
+		return nil, err
+	}
+	if err := AddUserDocs(doc, "/v1", v1); err != nil {
+		return nil, err
+	}
+	if err := AddUserDocs(doc, "/v2", v2); err != nil {
+		return nil, err
+	}
+	if err := ginswagger.Register(router, doc); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ginswagger/swaggertest"
+	"github.com/gin-gonic/gin"
+	"go-gin-YT/repository"
+)
+
+func TestOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router, err := newRouter(repository.NewMemoryUserRepository(), func() bool { return true }, func(context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := swaggertest.Spec(t, router)
+	swaggertest.Documented(t, router.Routes(), doc)
+
+	// 兩個版本的欄位名稱來自各自的 DTO
+	tests := []struct {
+		path     string
+		required []string
+	}{
+		{"/v1/users/", []string{"UserName", "UserPassword", "UserEmail"}},
+		{"/v2/users/", []string{"name", "password", "email"}},
+	}
+	for _, tt := range tests {
+		schema := doc.Paths.Find(tt.path).Post.RequestBody.Value.Content.Get("application/json").Schema.Value
+		if strings.Join(schema.Required, ",") != strings.Join(tt.required, ",") {
+			t.Errorf("POST %s required = %v, want %v", tt.path, schema.Required, tt.required)
+		}
+	}
+	if user := doc.Components.Schemas["UserV2"]; user == nil || user.Value.Properties["password"] != nil {
+		t.Errorf("UserV2 schema should not expose the password: %+v", user)
+	}
+}
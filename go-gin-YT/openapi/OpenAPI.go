@@ -0,0 +1,110 @@
+// Package openapi 從 handler 使用的 DTO 型別產生 OpenAPI 3 文件，文件由 ginswagger 提供
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+// ErrorResponse 是 handler 回傳錯誤時的格式 gin.H{"error": ...}
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func New(title, version string) *openapi3.T {
+	return &openapi3.T{
+		OpenAPI:    "3.0.3",
+		Info:       &openapi3.Info{Title: title, Version: version},
+		Paths:      openapi3.NewPaths(),
+		Components: &openapi3.Components{Schemas: openapi3.Schemas{}},
+	}
+}
+
+// Schema 用 reflection 產生 v 的型別的 schema 並放進 components，
+// 欄位名稱來自 json tag，必填與長度限制來自 gin 的 binding tag
+func Schema(doc *openapi3.T, v interface{}) (*openapi3.SchemaRef, error) {
+	return openapi3gen.NewSchemaRefForValue(v, doc.Components.Schemas,
+		openapi3gen.CreateComponentSchemas(openapi3gen.ExportComponentSchemasOptions{
+			ExportComponentSchemas: true,
+			ExportTopLevelSchema:   true,
+		}),
+		openapi3gen.SchemaCustomizer(binding),
+	)
+}
+
+// Operation 建立一個回傳 JSON 的 operation，responses 是狀態碼對應的回應內容，nil 代表沒有 body
+func Operation(doc *openapi3.T, id, summary string, request interface{}, responses map[int]interface{}) (*openapi3.Operation, error) {
+	op := openapi3.NewOperation()
+	op.OperationID = id
+	op.Summary = summary
+	if request != nil {
+		schema, err := Schema(doc, request)
+		if err != nil {
+			return nil, err
+		}
+		op.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(schema)}
+	}
+	for status, body := range responses {
+		response := openapi3.NewResponse().WithDescription(http.StatusText(status))
+		if body != nil {
+			schema, err := Schema(doc, body)
+			if err != nil {
+				return nil, err
+			}
+			response.WithJSONSchemaRef(schema)
+		}
+		op.AddResponse(status, response)
+	}
+	return op, nil
+}
+
+// SliceOf 回傳元素型別與 v 相同的空 slice，用來描述回傳陣列的 response
+func SliceOf(v interface{}) interface{} {
+	return reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(v)), 0, 0).Interface()
+}
+
+// binding 把 binding tag 的 required、min、max、email 轉成 schema 的限制
+func binding(_ string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) error {
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name != "" && name != "-" && hasRule(field.Tag, "required") {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		return nil
+	}
+	if t.Kind() != reflect.String {
+		return nil
+	}
+	for _, rule := range strings.Split(tag.Get("binding"), ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		n, _ := strconv.ParseInt(value, 10, 64)
+		switch key {
+		case "required":
+			schema.MinLength = 1
+		case "min":
+			schema.WithMinLength(n)
+		case "max":
+			schema.WithMaxLength(n)
+		case "email":
+			schema.Format = "email"
+		}
+	}
+	return nil
+}
+
+func hasRule(tag reflect.StructTag, name string) bool {
+	for _, rule := range strings.Split(tag.Get("binding"), ",") {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
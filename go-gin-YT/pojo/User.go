@@ -1,23 +1,35 @@
 package pojo
 
-import "go-gin-YT/database"
+import "golang.org/x/crypto/bcrypt"
 
+// PasswordCost 是 bcrypt 的 cost，測試時可以調成 bcrypt.MinCost 加快速度
+var PasswordCost = bcrypt.DefaultCost
+
+// User 是存進資料庫的資料，Password 是 bcrypt 雜湊。
+// API 不直接輸出 User，而是轉成各版本的 DTO (UserV1.go、UserV2.go)
 type User struct {
-	Id       int    `json:"UserId"`
-	Name     string `json:"UserName"`
-	Password string `json:"UserPassword"`
-	Email    string `json:"UserEmail"`
+	Id       int    `json:"-" gorm:"primaryKey"`
+	Name     string `json:"-"`
+	Password string `json:"-"`
+	Email    string `json:"-"`
+}
+
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	return nil
 }
 
-func FindAllUsers() []User {
-	var users []User
-	database.DB.Find(&users)
-	return users
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
 }
 
-func FindUserById(id int) User {
-	var user User
-	//database.DB.First(&user, id)
-	database.DB.Where("id = ?", id).First(&user)
-	return user
+// UserFields 是各版本的請求轉換後的內容，Password 為空代表不修改
+type UserFields struct {
+	Name     string
+	Email    string
+	Password string
 }
@@ -0,0 +1,43 @@
+package pojo
+
+// /v1 沿用原本的欄位名稱，但不再回傳密碼
+
+type UserV1 struct {
+	Id    int    `json:"UserId"`
+	Name  string `json:"UserName"`
+	Email string `json:"UserEmail"`
+}
+
+func NewUserV1(user User) UserV1 {
+	return UserV1{Id: user.Id, Name: user.Name, Email: user.Email}
+}
+
+type CreateUserV1 struct {
+	Name     string `json:"UserName" binding:"required,max=100"`
+	Password string `json:"UserPassword" binding:"required,min=8,max=72"`
+	Email    string `json:"UserEmail" binding:"required,email,max=100"`
+}
+
+func (r *CreateUserV1) Fields() UserFields {
+	return UserFields{Name: r.Name, Email: r.Email, Password: r.Password}
+}
+
+// UpdateUserV1 的 UserPassword 可以不帶，不帶就保留原本的密碼
+type UpdateUserV1 struct {
+	Name     string `json:"UserName" binding:"required,max=100"`
+	Password string `json:"UserPassword" binding:"omitempty,min=8,max=72"`
+	Email    string `json:"UserEmail" binding:"required,email,max=100"`
+}
+
+func (r *UpdateUserV1) Fields() UserFields {
+	return UserFields{Name: r.Name, Email: r.Email, Password: r.Password}
+}
+
+type ChangePasswordV1 struct {
+	OldPassword string `json:"OldPassword" binding:"required"`
+	NewPassword string `json:"NewPassword" binding:"required,min=8,max=72"`
+}
+
+func (r *ChangePasswordV1) Passwords() (string, string) {
+	return r.OldPassword, r.NewPassword
+}
@@ -0,0 +1,42 @@
+package pojo
+
+// /v2 的 JSON 欄位一律使用 snake_case
+
+type UserV2 struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func NewUserV2(user User) UserV2 {
+	return UserV2{Id: user.Id, Name: user.Name, Email: user.Email}
+}
+
+type CreateUserV2 struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+	Email    string `json:"email" binding:"required,email,max=100"`
+}
+
+func (r *CreateUserV2) Fields() UserFields {
+	return UserFields{Name: r.Name, Email: r.Email, Password: r.Password}
+}
+
+type UpdateUserV2 struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`
+	Email    string `json:"email" binding:"required,email,max=100"`
+}
+
+func (r *UpdateUserV2) Fields() UserFields {
+	return UserFields{Name: r.Name, Email: r.Email, Password: r.Password}
+}
+
+type ChangePasswordV2 struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+func (r *ChangePasswordV2) Passwords() (string, string) {
+	return r.OldPassword, r.NewPassword
+}
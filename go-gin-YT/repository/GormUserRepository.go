@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+
+	"go-gin-YT/database"
+	"go-gin-YT/pojo"
+	"gorm.io/gorm"
+)
+
+// GormUserRepository 每次都使用 database.DB，所以可以在連上資料庫之前建立
+type GormUserRepository struct{}
+
+func (GormUserRepository) FindAll() ([]pojo.User, error) {
+	users := []pojo.User{}
+	err := database.DB.Order("id").Find(&users).Error
+	return users, err
+}
+
+func (GormUserRepository) FindById(id int) (pojo.User, error) {
+	var user pojo.User
+	err := database.DB.First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (GormUserRepository) Create(user *pojo.User) error {
+	user.Id = 0
+	return database.DB.Create(user).Error
+}
+
+func (GormUserRepository) Update(id int, user *pojo.User) error {
+	user.Id = id
+	result := database.DB.Model(&pojo.User{Id: id}).Select("*").Updates(user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (GormUserRepository) Delete(id int) error {
+	result := database.DB.Delete(&pojo.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
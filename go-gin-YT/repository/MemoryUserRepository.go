@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+
+	"go-gin-YT/pojo"
+)
+
+// MemoryUserRepository 把使用者存在記憶體，重啟後就會消失，適合本機開發與測試
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[int]pojo.User
+	nextId int
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[int]pojo.User), nextId: 1}
+}
+
+func (r *MemoryUserRepository) FindAll() ([]pojo.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users := make([]pojo.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Id < users[j].Id })
+	return users, nil
+}
+
+func (r *MemoryUserRepository) FindById(id int) (pojo.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return pojo.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *MemoryUserRepository) Create(user *pojo.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.Id = r.nextId
+	r.nextId++
+	r.users[user.Id] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) Update(id int, user *pojo.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return ErrUserNotFound
+	}
+	user.Id = id
+	r.users[id] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return ErrUserNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
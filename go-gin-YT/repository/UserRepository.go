@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+
+	"go-gin-YT/pojo"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// UserRepository 是使用者的儲存層，id 一律由 repository 產生，找不到時回傳 ErrUserNotFound
+type UserRepository interface {
+	FindAll() ([]pojo.User, error)
+	FindById(id int) (pojo.User, error)
+	// Create 會忽略 user.Id，成功後寫回新的 id
+	Create(user *pojo.User) error
+	// Update 以 id 為準覆蓋其他欄位
+	Update(id int, user *pojo.User) error
+	Delete(id int) error
+}
+
+// New 依 store 建立 repository，"memory" 是不需要資料庫的記憶體版本，其他都使用 database.DB
+func New(store string) (UserRepository, error) {
+	switch store {
+	case "memory":
+		return NewMemoryUserRepository(), nil
+	case "postgres", "":
+		return GormUserRepository{}, nil
+	}
+	return nil, errors.New("unknown user store " + store)
+}
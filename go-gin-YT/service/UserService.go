@@ -1,65 +1,175 @@
 package service
 
 import (
-	"github.com/gin-gonic/gin"
-	"go-gin-YT/pojo"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go-gin-YT/pojo"
+	"go-gin-YT/repository"
+	"golang.org/x/crypto/bcrypt"
 )
 
-var userList []pojo.User
+// errWrongPassword 是修改密碼時舊密碼不正確
+var errWrongPassword = errors.New("old password is incorrect")
+
+// UserService 是 /users 的 handler，資料存取都交給 Users，JSON 格式由 Version 決定
+type UserService struct {
+	Users   repository.UserRepository
+	Version APIVersion
+}
 
 // FindAllUsers Get User
-func FindAllUsers(c *gin.Context) {
-	c.JSON(http.StatusOK, userList)
+func (s *UserService) FindAllUsers(c *gin.Context) {
+	users, err := s.Users.FindAll()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	response := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		response = append(response, s.Version.Response(user))
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-// PostUser Post User
-func PostUser(c *gin.Context) {
-	user := pojo.User{}
-	err := c.BindJSON(&user)
+// FindUserById Get User by id
+func (s *UserService) FindUserById(c *gin.Context) {
+	userId, ok := paramId(c)
+	if !ok {
+		return
+	}
+	user, err := s.Users.FindById(userId)
 	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.Version.Response(user))
+}
+
+// PostUser Post User
+func (s *UserService) PostUser(c *gin.Context) {
+	req := s.Version.CreateRequest()
+	if err := c.ShouldBindJSON(req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	userList = append(userList, user)
-	c.JSON(http.StatusOK, "success")
+	fields := req.Fields()
+	user := pojo.User{Name: fields.Name, Email: fields.Email}
+	if err := user.SetPassword(fields.Password); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := s.Users.Create(&user); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Header("Location", c.Request.URL.Path+strconv.Itoa(user.Id))
+	c.JSON(http.StatusCreated, s.Version.Response(user))
 }
 
 // DeleteUser delete user
-func DeleteUser(c *gin.Context) {
-	userId, _ := strconv.Atoi(c.Param("id"))
-	for i, user := range userList {
-		log.Println(i, user)
-		if user.Id == userId {
-			userList = append(userList[:i], userList[i+1:]...)
-			c.JSON(http.StatusOK, "success")
+func (s *UserService) DeleteUser(c *gin.Context) {
+	userId, ok := paramId(c)
+	if !ok {
+		return
+	}
+	if err := s.Users.Delete(userId); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, "success")
+}
+
+// PutUser put user，沒帶密碼時保留原本的密碼
+func (s *UserService) PutUser(c *gin.Context) {
+	userId, ok := paramId(c)
+	if !ok {
+		return
+	}
+	req := s.Version.UpdateRequest()
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := s.Users.FindById(userId)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	fields := req.Fields()
+	user.Name, user.Email = fields.Name, fields.Email
+	if fields.Password != "" {
+		if err := user.SetPassword(fields.Password); err != nil {
+			abortWithError(c, err)
 			return
 		}
 	}
-	c.JSON(http.StatusNotFound, "Error")
+	if err := s.Users.Update(userId, &user); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.Version.Response(user))
 }
 
-// PutUser put user
-func PutUser(c *gin.Context) {
-	beforeUser := pojo.User{}
-	err := c.BindJSON(&beforeUser)
+// ChangePassword 修改密碼，必須提供正確的舊密碼
+func (s *UserService) ChangePassword(c *gin.Context) {
+	userId, ok := paramId(c)
+	if !ok {
+		return
+	}
+	req := s.Version.PasswordRequest()
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := s.Users.FindById(userId)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, "Error")
+		abortWithError(c, err)
+		return
+	}
+	oldPassword, newPassword := req.Passwords()
+	if !user.CheckPassword(oldPassword) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errWrongPassword.Error()})
+		return
+	}
+	if err := user.SetPassword(newPassword); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if err := s.Users.Update(userId, &user); err != nil {
+		abortWithError(c, err)
 		return
 	}
+	c.Status(http.StatusNoContent)
+}
 
-	userId, _ := strconv.Atoi(c.Param("id"))
-	for i, user := range userList {
-		if user.Id == userId {
-			userList[i] = beforeUser
-			log.Println(userList[i])
-			c.JSON(http.StatusOK, "success")
-			return
-		}
+// paramId 解析路徑上的 :id，不合法時直接回應 400
+func paramId(c *gin.Context) (int, bool) {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil || userId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id " + c.Param("id")})
+		return 0, false
 	}
+	return userId, true
+}
 
-	c.JSON(http.StatusNotFound, "Error")
+// abortWithError 把 repository 與 bcrypt 的錯誤轉成 HTTP 狀態碼，其他錯誤不回傳細節
+func abortWithError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	// binding 的 max=72 算的是字元數，bcrypt 的上限是 72 bytes，中文密碼可能在這裡才超過
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at most 72 bytes"})
+		return
+	}
+	log.Println("user repository:", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 }
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go-gin-YT/pojo"
+	"go-gin-YT/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	pojo.PasswordCost = bcrypt.MinCost
+	os.Exit(m.Run())
+}
+
+// newRouter 掛上 /v1 與 /v2，兩個版本共用同一個 repository
+func newRouter() (*gin.Engine, repository.UserRepository) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	repo := repository.NewMemoryUserRepository()
+	for prefix, version := range map[string]APIVersion{"/v1/users": V1, "/v2/users": V2} {
+		users := &UserService{Users: repo, Version: version}
+		group := router.Group(prefix)
+		group.GET("/", users.FindAllUsers)
+		group.GET("/:id", users.FindUserById)
+		group.POST("/", users.PostUser)
+		group.DELETE("/:id", users.DeleteUser)
+		group.PUT("/:id", users.PutUser)
+		group.PUT("/:id/password", users.ChangePassword)
+	}
+	return router, repo
+}
+
+func do(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserCRUD(t *testing.T) {
+	router, _ := newRouter()
+	alice := `{"UserId":99,"UserName":"Alice","UserPassword":"secret123","UserEmail":"alice@example.com"}`
+
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+		wantBody                 string
+	}{
+		{"create ignores client id", "POST", "/v1/users/", alice, http.StatusCreated, `"UserId":1`},
+		{"create invalid email", "POST", "/v1/users/", `{"UserName":"Bob","UserPassword":"secret123","UserEmail":"bob"}`, http.StatusBadRequest, "Email"},
+		{"create missing name", "POST", "/v1/users/", `{"UserPassword":"secret123","UserEmail":"bob@example.com"}`, http.StatusBadRequest, "Name"},
+		{"create short password", "POST", "/v1/users/", `{"UserName":"Bob","UserPassword":"short","UserEmail":"bob@example.com"}`, http.StatusBadRequest, "Password"},
+		// 30 個中文字通過 max=72，但超過 bcrypt 的 72 bytes
+		{"create password over 72 bytes", "POST", "/v1/users/", `{"UserName":"Bob","UserPassword":"` + strings.Repeat("密", 30) + `","UserEmail":"bob@example.com"}`, http.StatusBadRequest, "72 bytes"},
+		{"get", "GET", "/v1/users/1", "", http.StatusOK, `"UserName":"Alice"`},
+		{"get missing", "GET", "/v1/users/2", "", http.StatusNotFound, "user not found"},
+		{"get invalid id", "GET", "/v1/users/abc", "", http.StatusBadRequest, "invalid user id"},
+		{"put", "PUT", "/v1/users/1", strings.Replace(alice, "Alice", "Alicia", 1), http.StatusOK, `"UserId":1`},
+		{"put missing", "PUT", "/v1/users/2", alice, http.StatusNotFound, "user not found"},
+		{"put without password", "PUT", "/v1/users/1", `{"UserName":"Alicia","UserEmail":"alice@example.com"}`, http.StatusOK, `"UserName":"Alicia"`},
+		{"put password over 72 bytes", "PUT", "/v1/users/1", `{"UserName":"Alicia","UserPassword":"` + strings.Repeat("密", 30) + `","UserEmail":"alice@example.com"}`, http.StatusBadRequest, "72 bytes"},
+		{"list", "GET", "/v1/users/", "", http.StatusOK, `"UserName":"Alicia"`},
+		{"delete", "DELETE", "/v1/users/1", "", http.StatusOK, "success"},
+		{"delete again", "DELETE", "/v1/users/1", "", http.StatusNotFound, "user not found"},
+		{"list empty", "GET", "/v1/users/", "", http.StatusOK, "[]"},
+	}
+	for _, tt := range tests {
+		w := do(router, tt.method, tt.path, tt.body)
+		if w.Code != tt.want || !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("%s: %d %s, want %d containing %s", tt.name, w.Code, w.Body, tt.want, tt.wantBody)
+		}
+		if strings.Contains(strings.ToLower(w.Body.String()), "password") && w.Code < 400 {
+			t.Errorf("%s: response exposes the password: %s", tt.name, w.Body)
+		}
+	}
+}
+
+func TestVersionedFields(t *testing.T) {
+	router, repo := newRouter()
+
+	w := do(router, "POST", "/v2/users/", `{"name":"Bob","password":"secret123","email":"bob@example.com"}`)
+	if w.Code != http.StatusCreated || w.Body.String() != `{"id":1,"name":"Bob","email":"bob@example.com"}` {
+		t.Fatalf("v2 create: %d %s", w.Code, w.Body)
+	}
+	// v2 不接受 v1 的欄位名稱
+	if w := do(router, "POST", "/v2/users/", `{"UserName":"Bob","UserPassword":"secret123","UserEmail":"bob@example.com"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("v2 create with v1 fields: %d %s", w.Code, w.Body)
+	}
+	if w := do(router, "GET", "/v1/users/1", ""); w.Body.String() != `{"UserId":1,"UserName":"Bob","UserEmail":"bob@example.com"}` {
+		t.Errorf("v1 get: %d %s", w.Code, w.Body)
+	}
+
+	user, err := repo.FindById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Password == "secret123" || !strings.HasPrefix(user.Password, "$2") || !user.CheckPassword("secret123") {
+		t.Errorf("stored password is not a bcrypt hash of the input: %q", user.Password)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	router, repo := newRouter()
+	do(router, "POST", "/v1/users/", `{"UserName":"Alice","UserPassword":"secret123","UserEmail":"alice@example.com"}`)
+
+	tests := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"wrong old password", "/v1/users/1/password", `{"OldPassword":"wrong-password","NewPassword":"changed123"}`, http.StatusForbidden},
+		{"new password too short", "/v1/users/1/password", `{"OldPassword":"secret123","NewPassword":"short"}`, http.StatusBadRequest},
+		{"new password over 72 bytes", "/v1/users/1/password", `{"OldPassword":"secret123","NewPassword":"` + strings.Repeat("密", 30) + `"}`, http.StatusBadRequest},
+		{"missing user", "/v1/users/2/password", `{"OldPassword":"secret123","NewPassword":"changed123"}`, http.StatusNotFound},
+		{"v1", "/v1/users/1/password", `{"OldPassword":"secret123","NewPassword":"changed123"}`, http.StatusNoContent},
+		{"v2 with the old password", "/v2/users/1/password", `{"old_password":"secret123","new_password":"again12345"}`, http.StatusForbidden},
+		{"v2", "/v2/users/1/password", `{"old_password":"changed123","new_password":"again12345"}`, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if w := do(router, "PUT", tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s: %d %s, want %d", tt.name, w.Code, w.Body, tt.want)
+		}
+	}
+
+	user, err := repo.FindById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.CheckPassword("again12345") {
+		t.Error("password was not changed")
+	}
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	router, _ := newRouter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			do(router, "POST", "/v1/users/", `{"UserName":"u","UserPassword":"secret123","UserEmail":"u@example.com"}`)
+		}()
+	}
+	wg.Wait()
+
+	var users []pojo.UserV1
+	if err := json.Unmarshal(do(router, "GET", "/v1/users/", "").Body.Bytes(), &users); err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]bool{}
+	for _, u := range users {
+		seen[u.Id] = true
+	}
+	if len(users) != 50 || len(seen) != 50 {
+		t.Errorf("got %d users with %d distinct ids, want 50", len(users), len(seen))
+	}
+}
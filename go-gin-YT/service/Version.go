@@ -0,0 +1,35 @@
+package service
+
+import "go-gin-YT/pojo"
+
+// UserRequest 是新增與修改使用者的請求
+type UserRequest interface {
+	Fields() pojo.UserFields
+}
+
+// PasswordRequest 是修改密碼的請求，回傳舊密碼與新密碼
+type PasswordRequest interface {
+	Passwords() (string, string)
+}
+
+// APIVersion 描述一個 API 版本的 JSON 格式，handler 的邏輯每個版本都一樣
+type APIVersion struct {
+	CreateRequest   func() UserRequest
+	UpdateRequest   func() UserRequest
+	PasswordRequest func() PasswordRequest
+	Response        func(pojo.User) interface{}
+}
+
+var V1 = APIVersion{
+	CreateRequest:   func() UserRequest { return &pojo.CreateUserV1{} },
+	UpdateRequest:   func() UserRequest { return &pojo.UpdateUserV1{} },
+	PasswordRequest: func() PasswordRequest { return &pojo.ChangePasswordV1{} },
+	Response:        func(user pojo.User) interface{} { return pojo.NewUserV1(user) },
+}
+
+var V2 = APIVersion{
+	CreateRequest:   func() UserRequest { return &pojo.CreateUserV2{} },
+	UpdateRequest:   func() UserRequest { return &pojo.UpdateUserV2{} },
+	PasswordRequest: func() PasswordRequest { return &pojo.ChangePasswordV2{} },
+	Response:        func(user pojo.User) interface{} { return pojo.NewUserV2(user) },
+}
@@ -0,0 +1,61 @@
+package src
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go-gin-YT/health"
+	"go-gin-YT/openapi"
+	"go-gin-YT/service"
+)
+
+// AddUserDocs 把 AddUserRouter 註冊的路由寫進 doc。路徑與 method 來自同一份 userRoutes，
+// request 與 response 的 schema 取自 users.Version 使用的 DTO，所以 v1、v2 的欄位名稱一定和 handler 實際收送的一致；
+// 狀態碼無法從 handler 推導，寫在 userRoutes 的每個路由上
+func AddUserDocs(doc *openapi3.T, prefix string, users *service.UserService) error {
+	tag := strings.TrimPrefix(prefix, "/")
+	unavailable, err := openapi.Schema(doc, openapi.ErrorResponse{})
+	if err != nil {
+		return err
+	}
+	id := openapi3.NewPathParameter("id").WithSchema(openapi3.NewIntegerSchema().WithMin(1))
+
+	for _, route := range userRoutes(users) {
+		op, err := openapi.Operation(doc, tag+route.id, route.summary, route.request, route.responses)
+		if err != nil {
+			return err
+		}
+		op.Tags = []string{tag}
+		path := strings.ReplaceAll(route.path, ":id", "{id}")
+		if path != route.path {
+			op.AddParameter(id)
+		}
+		// 連上資料庫之前 ReadinessGate 會回 503
+		op.AddResponse(http.StatusServiceUnavailable, openapi3.NewResponse().
+			WithDescription("Service is starting").WithJSONSchemaRef(unavailable))
+		doc.AddOperation(prefix+"/users"+path, route.method, op)
+	}
+	return nil
+}
+
+// AddHealthDocs 寫入 /healthz 與 /readyz
+func AddHealthDocs(doc *openapi3.T) error {
+	status := health.Status{}
+	healthz, err := openapi.Operation(doc, "Healthz", "Liveness probe", nil, map[int]interface{}{
+		http.StatusOK: status,
+	})
+	if err != nil {
+		return err
+	}
+	readyz, err := openapi.Operation(doc, "Readyz", "Readiness probe, 503 until the database is reachable", nil, map[int]interface{}{
+		http.StatusOK:                 status,
+		http.StatusServiceUnavailable: status,
+	})
+	if err != nil {
+		return err
+	}
+	doc.AddOperation("/healthz", http.MethodGet, healthz)
+	doc.AddOperation("/readyz", http.MethodGet, readyz)
+	return nil
+}
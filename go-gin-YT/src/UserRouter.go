@@ -1,14 +1,60 @@
 package src
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"go-gin-YT/openapi"
+	"go-gin-YT/pojo"
 	"go-gin-YT/service"
 )
 
-func AddUserRouter(r *gin.RouterGroup) {
+// userRoute 是 /users 底下的一個路由，AddUserRouter 用它註冊 handler，AddUserDocs 用它產生文件
+type userRoute struct {
+	method, path string
+	handler      gin.HandlerFunc
+	id, summary  string
+	request      interface{}
+	responses    map[int]interface{} // handler 可能回傳的狀態碼與 body，nil 代表沒有 body
+}
+
+func userRoutes(users *service.UserService) []userRoute {
+	user := users.Version.Response(pojo.User{})
+	errorResponse := openapi.ErrorResponse{}
+	return []userRoute{
+		{http.MethodGet, "/", users.FindAllUsers, "ListUsers", "List users", nil, map[int]interface{}{
+			http.StatusOK: openapi.SliceOf(user),
+		}},
+		{http.MethodGet, "/:id", users.FindUserById, "GetUser", "Get a user", nil, map[int]interface{}{
+			http.StatusOK:         user,
+			http.StatusBadRequest: errorResponse,
+			http.StatusNotFound:   errorResponse,
+		}},
+		{http.MethodPost, "/", users.PostUser, "CreateUser", "Create a user", users.Version.CreateRequest(), map[int]interface{}{
+			http.StatusCreated:    user,
+			http.StatusBadRequest: errorResponse,
+		}},
+		{http.MethodDelete, "/:id", users.DeleteUser, "DeleteUser", "Delete a user", nil, map[int]interface{}{
+			http.StatusOK:       "success",
+			http.StatusNotFound: errorResponse,
+		}},
+		{http.MethodPut, "/:id", users.PutUser, "UpdateUser", "Replace a user, the password is kept when omitted", users.Version.UpdateRequest(), map[int]interface{}{
+			http.StatusOK:         user,
+			http.StatusBadRequest: errorResponse,
+			http.StatusNotFound:   errorResponse,
+		}},
+		{http.MethodPut, "/:id/password", users.ChangePassword, "ChangePassword", "Change the password, the old password is required", users.Version.PasswordRequest(), map[int]interface{}{
+			http.StatusNoContent:  nil,
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden:  errorResponse,
+			http.StatusNotFound:   errorResponse,
+		}},
+	}
+}
+
+func AddUserRouter(r *gin.RouterGroup, users *service.UserService) {
 	user := r.Group("/users")
-	user.GET("/", service.FindAllUsers)
-	user.POST("/", service.PostUser)
-	user.DELETE("/:id", service.DeleteUser)
-	user.PUT("/:id", service.PutUser)
+	for _, route := range userRoutes(users) {
+		user.Handle(route.method, route.path, route.handler)
+	}
 }
@@ -0,0 +1,342 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config 依序由預設值、設定檔 (YAML)、環境變數、命令列參數載入，後面的會覆蓋前面的。
+// 每個欄位的 env 與 flag tag 就是對應的環境變數與參數名稱
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Cache    CacheConfig    `yaml:"cache"`
+}
+
+type ServerConfig struct {
+	// REST 與 gRPC 共用的位址
+	Addr string `yaml:"addr" env:"SERVER_ADDR" flag:"addr"`
+	// client 沒帶 deadline 時 RPC 的預設期限
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"SERVER_REQUEST_TIMEOUT" flag:"request-timeout"`
+	// 收到 SIGTERM 後等待進行中請求完成的時間
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout"`
+	Reflection      bool          `yaml:"reflection" env:"SERVER_REFLECTION" flag:"reflection"`
+	LogFormat       string        `yaml:"log_format" env:"LOG_FORMAT" flag:"log-format"` // text 或 json
+	TLS             TLSConfig     `yaml:"tls"`
+}
+
+// TLSConfig 沒有設定 cert_file 時使用明文 (h2c)。
+// 設定 client_ca_file 就會驗證 client 憑證，可以用憑證代替 token，見 auth.CertificateClaims
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file" env:"TLS_CERT_FILE" flag:"tls-cert-file"`
+	KeyFile      string `yaml:"key_file" env:"TLS_KEY_FILE" flag:"tls-key-file"`
+	ClientCAFile string `yaml:"client_ca_file" env:"TLS_CLIENT_CA_FILE" flag:"tls-client-ca-file"`
+	// ClientAuth 為 optional 時 client 可以不帶憑證，require 時一定要帶
+	ClientAuth string `yaml:"client_auth" env:"TLS_CLIENT_AUTH" flag:"tls-client-auth"`
+	// 每隔多久檢查一次憑證檔，有變動就換上新的憑證，不需要重啟
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"TLS_RELOAD_INTERVAL" flag:"tls-reload-interval"`
+}
+
+// Enabled 回傳是否要以 TLS 提供服務
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+type DatabaseConfig struct {
+	Host         string `yaml:"host" env:"DB_HOST" flag:"db-host"`
+	Port         int    `yaml:"port" env:"DB_PORT" flag:"db-port"`
+	User         string `yaml:"user" env:"DB_USER" flag:"db-user"`
+	Password     string `yaml:"password" env:"DB_PASSWORD"`
+	PasswordFile string `yaml:"password_file" env:"DB_PASSWORD_FILE" flag:"db-password-file"`
+	Name         string `yaml:"name" env:"DB_NAME" flag:"db-name"`
+	SSLMode      string `yaml:"sslmode" env:"DB_SSLMODE" flag:"db-sslmode"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" flag:"db-max-open-conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" flag:"db-max-idle-conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" flag:"db-conn-max-lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" flag:"db-conn-max-idle-time"`
+}
+
+type AuthConfig struct {
+	// 沒有設定時使用隨機值，重啟後所有 token 都會失效
+	JWTSecret     string        `yaml:"jwt_secret" env:"JWT_SECRET"`
+	JWTSecretFile string        `yaml:"jwt_secret_file" env:"JWT_SECRET_FILE" flag:"jwt-secret-file"`
+	AccessTTL     time.Duration `yaml:"access_ttl" env:"JWT_ACCESS_TTL" flag:"jwt-access-ttl"`
+	RefreshTTL    time.Duration `yaml:"refresh_ttl" env:"JWT_REFRESH_TTL" flag:"jwt-refresh-ttl"`
+}
+
+// CacheConfig 是使用者查詢的 Redis 快取，沒有設定 redis_addr 時不使用快取。
+// Redis 連不上時服務照常運作，只是每次都讀資料庫
+type CacheConfig struct {
+	RedisAddr         string `yaml:"redis_addr" env:"REDIS_ADDR" flag:"redis-addr"`
+	RedisPassword     string `yaml:"redis_password" env:"REDIS_PASSWORD"`
+	RedisPasswordFile string `yaml:"redis_password_file" env:"REDIS_PASSWORD_FILE" flag:"redis-password-file"`
+	RedisDB           int    `yaml:"redis_db" env:"REDIS_DB" flag:"redis-db"`
+
+	UserTTL     time.Duration `yaml:"user_ttl" env:"CACHE_USER_TTL" flag:"cache-user-ttl"`
+	ListTTL     time.Duration `yaml:"list_ttl" env:"CACHE_LIST_TTL" flag:"cache-list-ttl"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env:"CACHE_NEGATIVE_TTL" flag:"cache-negative-ttl"`
+}
+
+func (c CacheConfig) Enabled() bool {
+	return c.RedisAddr != ""
+}
+
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true, "require": true, "verify-ca": true, "verify-full": true,
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8000",
+			RequestTimeout:  30 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			Reflection:      true,
+			LogFormat:       "text",
+			TLS: TLSConfig{
+				ClientAuth:     "optional",
+				ReloadInterval: time.Minute,
+			},
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "root",
+			Name:            "go",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			AccessTTL:  15 * time.Minute,
+			RefreshTTL: 7 * 24 * time.Hour,
+		},
+		Cache: CacheConfig{
+			UserTTL:     5 * time.Minute,
+			ListTTL:     time.Minute,
+			NegativeTTL: 30 * time.Second,
+		},
+	}
+}
+
+// Load 解析 args (不含程式名稱) 並回傳驗證過的設定，以及參數之後剩下的子命令，例如 migrate up。
+// 設定檔路徑來自 -config 或 CONFIG_FILE
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file (env CONFIG_FILE)")
+	applyFlags := bindFlags(fs, reflect.ValueOf(&cfg).Elem())
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read config file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil {
+			return nil, nil, fmt.Errorf("parse config file %s: %w", *configFile, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		return nil, nil, err
+	}
+	// flag 的值在 Parse 時先暫存，等設定檔與環境變數套用後才覆蓋上去
+	if err := applyFlags(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := cfg.readSecretFiles(); err != nil {
+		return nil, nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, fs.Args(), nil
+}
+
+// Validate 一次回報所有不合法的設定
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.RequestTimeout >= 0, "server.request_timeout must not be negative")
+	check(c.Server.ShutdownTimeout >= 0, "server.shutdown_timeout must not be negative")
+	check(c.Server.LogFormat == "text" || c.Server.LogFormat == "json", "server.log_format %q must be text or json", c.Server.LogFormat)
+
+	tls := c.Server.TLS
+	check((tls.CertFile == "") == (tls.KeyFile == ""), "server.tls.cert_file and server.tls.key_file must be set together")
+	check(tls.ClientCAFile == "" || tls.Enabled(), "server.tls.client_ca_file requires server.tls.cert_file")
+	check(tls.ClientAuth == "optional" || tls.ClientAuth == "require", "server.tls.client_auth %q must be optional or require", tls.ClientAuth)
+	check(tls.ClientAuth != "require" || tls.ClientCAFile != "", "server.tls.client_auth require needs server.tls.client_ca_file")
+	check(tls.ReloadInterval > 0, "server.tls.reload_interval must be positive")
+
+	db := c.Database
+	check(db.Host != "", "database.host is required")
+	check(db.Port > 0 && db.Port <= 65535, "database.port %d is out of range", db.Port)
+	check(db.User != "", "database.user is required")
+	check(db.Name != "", "database.name is required")
+	check(sslModes[db.SSLMode], "database.sslmode %q is not a valid libpq sslmode", db.SSLMode)
+	check(db.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(db.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(db.MaxOpenConns == 0 || db.MaxIdleConns <= db.MaxOpenConns,
+		"database.max_idle_conns (%d) must not exceed max_open_conns (%d)", db.MaxIdleConns, db.MaxOpenConns)
+	check(db.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(db.ConnMaxIdleTime >= 0, "database.conn_max_idle_time must not be negative")
+
+	check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= 32, "auth.jwt_secret must be at least 32 bytes")
+	check(c.Auth.AccessTTL > 0, "auth.access_ttl must be positive")
+	check(c.Auth.RefreshTTL > c.Auth.AccessTTL, "auth.refresh_ttl must be longer than auth.access_ttl")
+
+	check(c.Cache.RedisDB >= 0, "cache.redis_db must not be negative")
+	check(c.Cache.UserTTL > 0, "cache.user_ttl must be positive")
+	check(c.Cache.ListTTL > 0, "cache.list_ttl must be positive")
+	check(c.Cache.NegativeTTL > 0, "cache.negative_ttl must be positive")
+
+	return errors.Join(errs...)
+}
+
+// DSN 組出 libpq key=value 格式的連線字串
+func (d DatabaseConfig) DSN() string {
+	quote := func(v string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quote(d.Host), d.Port, quote(d.User), quote(d.Password), quote(d.Name), d.SSLMode)
+}
+
+// readSecretFiles 讓 docker / k8s 的 secret 可以用檔案掛載，*_file 有設定時優先於明文
+func (c *Config) readSecretFiles() error {
+	for _, secret := range []struct {
+		file  string
+		value *string
+	}{
+		{c.Database.PasswordFile, &c.Database.Password},
+		{c.Auth.JWTSecretFile, &c.Auth.JWTSecret},
+		{c.Cache.RedisPasswordFile, &c.Cache.RedisPassword},
+	} {
+		if secret.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(secret.file)
+		if err != nil {
+			return fmt.Errorf("read secret file: %w", err)
+		}
+		*secret.value = strings.TrimRight(string(data), "\r\n")
+	}
+	return nil
+}
+
+// applyEnv 把有 env tag 且有設定的環境變數寫進對應欄位
+func applyEnv(v reflect.Value) error {
+	return walk(v, func(field reflect.Value, tag reflect.StructTag) error {
+		name := tag.Get("env")
+		if name == "" {
+			return nil
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		if err := setValue(field, value); err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// bindFlags 註冊有 flag tag 的欄位，回傳的函式會把有出現在命令列的值寫回欄位
+func bindFlags(fs *flag.FlagSet, v reflect.Value) func() error {
+	var setters []func() error
+	_ = walk(v, func(field reflect.Value, tag reflect.StructTag) error {
+		name := tag.Get("flag")
+		if name == "" {
+			return nil
+		}
+		usage := "env " + tag.Get("env")
+		fs.Func(name, usage, func(value string) error {
+			if err := setValue(reflect.New(field.Type()).Elem(), value); err != nil {
+				return err
+			}
+			setters = append(setters, func() error { return setValue(field, value) })
+			return nil
+		})
+		return nil
+	})
+	return func() error {
+		for _, set := range setters {
+			if err := set(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func walk(v reflect.Value, fn func(field reflect.Value, tag reflect.StructTag) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := walk(field, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(field, t.Field(i).Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setValue(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	default:
+		return fmt.Errorf("unsupported config type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	secret := filepath.Join(dir, "db_password")
+	writeFile(t, file, "server:\n  addr: \":9000\"\n  tls:\n    cert_file: tls.crt\n    key_file: tls.key\n    client_ca_file: ca.pem\ndatabase:\n  host: db.local\n  port: 5433\n  name: from-file\n")
+	writeFile(t, secret, "s3cret\n")
+
+	t.Setenv("DB_HOST", "db.env")
+	t.Setenv("DB_PASSWORD_FILE", secret)
+	t.Setenv("DB_CONN_MAX_LIFETIME", "1h")
+	t.Setenv("SERVER_REFLECTION", "false")
+	t.Setenv("TLS_CLIENT_AUTH", "require")
+
+	cfg, args, err := Load([]string{"-config", file, "-db-host", "db.flag", "migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"default", cfg.Database.User, "root"},
+		{"file", cfg.Database.Name, "from-file"},
+		{"file", cfg.Server.Addr, ":9000"},
+		{"env over file", cfg.Database.ConnMaxLifetime, time.Hour},
+		{"flag over env", cfg.Database.Host, "db.flag"},
+		{"env bool", cfg.Server.Reflection, false},
+		{"nested file", cfg.Server.TLS.ClientCAFile, "ca.pem"},
+		{"nested env", cfg.Server.TLS.ClientAuth, "require"},
+		{"nested default", cfg.Server.TLS.ReloadInterval, time.Minute},
+		{"secret file", cfg.Database.Password, "s3cret"},
+		{"remaining args", len(args), 2},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Port = 0
+	cfg.Database.MaxOpenConns = 2
+	cfg.Database.MaxIdleConns = 10
+	cfg.Auth.JWTSecret = "short"
+	cfg.Server.TLS.ClientCAFile = "ca.pem"
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	cfg = Default()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config should be valid: %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
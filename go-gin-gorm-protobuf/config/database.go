@@ -8,11 +8,22 @@ import (
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
-	dsn := "host=localhost user=root password=Root&123 dbname=go port=5432 sslmode=disable"
+func ConnectDatabase(cfg DatabaseConfig) {
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		// 讓 unique index 衝突變成 gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		log.Fatal("Failed to connect to database", err)
 	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		log.Fatal("Failed to get database handle", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 }
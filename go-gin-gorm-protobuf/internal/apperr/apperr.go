@@ -0,0 +1,96 @@
+// Package apperr 定義 REST 與 gRPC 共用的錯誤模型。
+// service 與 repository 回傳 *Error (通常用 fmt.Errorf 的 %w 包起來)，
+// 對外時再由 ToStatus 轉成 gRPC status，或由 WriteProblem 轉成 RFC 7807 problem+json
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Domain 是 google.rpc.ErrorInfo 的 domain
+const Domain = "user-service"
+
+type Kind int
+
+const (
+	// Internal 是未知錯誤的預設分類，訊息不會回傳給呼叫端
+	Internal Kind = iota
+	NotFound
+	Conflict
+	Validation
+	Unauthorized
+	Forbidden
+	// Unavailable 代表暫時無法處理，client 可以稍後重試
+	Unavailable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NotFound:
+		return "not_found"
+	case Conflict:
+		return "conflict"
+	case Validation:
+		return "validation"
+	case Unauthorized:
+		return "unauthorized"
+	case Forbidden:
+		return "forbidden"
+	case Unavailable:
+		return "unavailable"
+	}
+	return "internal"
+}
+
+// Error 是可以安全回傳給呼叫端的錯誤，Reason 是給程式判斷用的 UPPER_SNAKE_CASE 代碼
+type Error struct {
+	Kind       Kind
+	Reason     string
+	Message    string
+	Violations []Violation // 只有 Validation 會有
+}
+
+// Violation 是單一欄位的驗證錯誤
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func New(kind Kind, reason string, message string) *Error {
+	return &Error{Kind: kind, Reason: reason, Message: message}
+}
+
+// Invalid 回傳一個包含所有欄位錯誤的 Validation 錯誤
+func Invalid(violations ...Violation) *Error {
+	return &Error{Kind: Validation, Reason: "INVALID_ARGUMENT", Message: "invalid argument", Violations: violations}
+}
+
+func (e *Error) Error() string {
+	if len(e.Violations) == 0 {
+		return e.Message
+	}
+	fields := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		fields[i] = fmt.Sprintf("%s: %s", v.Field, v.Message)
+	}
+	return e.Message + ": " + strings.Join(fields, "; ")
+}
+
+// As 取出 err 鏈中的 *Error，沒有的話回傳 nil
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return nil
+}
+
+// KindOf 回傳 err 的分類，不是 *Error 時為 Internal
+func KindOf(err error) Kind {
+	if e := As(err); e != nil {
+		return e.Kind
+	}
+	return Internal
+}
@@ -0,0 +1,90 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-gin-gorm-protobuf/internal/requestid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMapping(t *testing.T) {
+	notFound := New(NotFound, "USER_NOT_FOUND", "user not found")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantStatus int
+		wantReason string
+		wantDetail string
+		wantFields int
+	}{
+		{"wrapped not found", fmt.Errorf("user with id 7: %w", notFound),
+			codes.NotFound, http.StatusNotFound, "USER_NOT_FOUND", "user with id 7: user not found", 0},
+		{"conflict", New(Conflict, "EMAIL_TAKEN", "email already in use"),
+			codes.AlreadyExists, http.StatusConflict, "EMAIL_TAKEN", "email already in use", 0},
+		{"validation", Invalid(Violation{"name", "is required"}, Violation{"email", "is required"}),
+			codes.InvalidArgument, http.StatusBadRequest, "INVALID_ARGUMENT", "invalid argument: name: is required; email: is required", 2},
+		{"unauthorized", New(Unauthorized, "INVALID_TOKEN", "invalid token"),
+			codes.Unauthenticated, http.StatusUnauthorized, "INVALID_TOKEN", "invalid token", 0},
+		{"forbidden", New(Forbidden, "PERMISSION_DENIED", "permission denied"),
+			codes.PermissionDenied, http.StatusForbidden, "PERMISSION_DENIED", "permission denied", 0},
+		{"unavailable", New(Unavailable, "SHUTTING_DOWN", "server is shutting down"),
+			codes.Unavailable, http.StatusServiceUnavailable, "SHUTTING_DOWN", "server is shutting down", 0},
+		{"unknown error is hidden", errors.New("pq: connection refused"),
+			codes.Internal, http.StatusInternalServerError, "INTERNAL", "internal error", 0},
+		{"existing status passes through", status.Error(codes.Unimplemented, "not here"),
+			codes.Unimplemented, http.StatusNotImplemented, "", "not here", 0},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded),
+			codes.DeadlineExceeded, http.StatusGatewayTimeout, "", "query: context deadline exceeded", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := requestid.NewContext(context.Background(), "req-1")
+			st := ToStatus(ctx, tt.err)
+			if st.Code() != tt.wantCode {
+				t.Errorf("code = %v, want %v", st.Code(), tt.wantCode)
+			}
+
+			r := httptest.NewRequest("GET", "/users/7", nil).WithContext(ctx)
+			p := NewProblem(r, tt.err)
+			if p.Status != tt.wantStatus || p.Title != http.StatusText(tt.wantStatus) {
+				t.Errorf("status = %d %q, want %d", p.Status, p.Title, tt.wantStatus)
+			}
+			if p.Reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", p.Reason, tt.wantReason)
+			}
+			if p.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", p.Detail, tt.wantDetail)
+			}
+			if len(p.Errors) != tt.wantFields {
+				t.Errorf("errors = %v, want %d entries", p.Errors, tt.wantFields)
+			}
+			if p.Instance != "/users/7" {
+				t.Errorf("instance = %q", p.Instance)
+			}
+			if tt.wantReason != "" && p.RequestID != "req-1" {
+				t.Errorf("request_id = %q, want req-1", p.RequestID)
+			}
+		})
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, httptest.NewRequest("GET", "/x", nil), New(NotFound, "X", "x"))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != MIMEProblem {
+		t.Errorf("content type = %q", ct)
+	}
+}
@@ -0,0 +1,86 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/go-gin-gorm-protobuf/internal/requestid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+var grpcCodes = map[Kind]codes.Code{
+	Internal:     codes.Internal,
+	NotFound:     codes.NotFound,
+	Conflict:     codes.AlreadyExists,
+	Validation:   codes.InvalidArgument,
+	Unauthorized: codes.Unauthenticated,
+	Forbidden:    codes.PermissionDenied,
+	Unavailable:  codes.Unavailable,
+}
+
+// ToStatus 把 err 轉成 gRPC status，details 帶有 ErrorInfo、欄位錯誤 (BadRequest) 與 request ID。
+// 已經是 status 的錯誤原樣回傳，其他未分類的錯誤會記錄下來並只回傳 "internal error"
+func ToStatus(ctx context.Context, err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	}
+
+	e := As(err)
+	if e == nil || e.Kind == Internal {
+		slog.ErrorContext(ctx, "internal error", "request_id", requestid.FromContext(ctx), "err", err)
+		e = New(Internal, "INTERNAL", "internal error")
+		err = e
+	}
+
+	st := status.New(grpcCodes[e.Kind], err.Error())
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: e.Reason, Domain: Domain}}
+	if len(e.Violations) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, v := range e.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Message,
+			})
+		}
+		details = append(details, br)
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		details = append(details, &errdetails.RequestInfo{RequestId: id})
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// UnaryServerInterceptor 讓 handler 與後面的 interceptor 可以直接回傳 *Error
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, ToStatus(ctx, err).Err()
+		}
+		return resp, nil
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return ToStatus(ss.Context(), err).Err()
+		}
+		return nil
+	}
+}
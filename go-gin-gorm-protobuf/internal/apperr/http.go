@@ -0,0 +1,73 @@
+package apperr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// MIMEProblem 是 RFC 7807 的 Content-Type
+const MIMEProblem = "application/problem+json"
+
+// Problem 是 RFC 7807 的錯誤內容，reason、errors、request_id 是擴充欄位
+type Problem struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Instance  string      `json:"instance,omitempty"`
+	Reason    string      `json:"reason,omitempty"`
+	Errors    []Violation `json:"errors,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// NewProblem 經由 ToStatus 轉換，所以 HTTP 與 gRPC 看到的錯誤內容一致
+func NewProblem(r *http.Request, err error) *Problem {
+	st := ToStatus(r.Context(), err)
+	code := runtime.HTTPStatusFromCode(st.Code())
+
+	p := &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(code),
+		Status:   code,
+		Detail:   st.Message(),
+		Instance: r.URL.Path,
+	}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			p.Reason = d.Reason
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				p.Errors = append(p.Errors, Violation{Field: v.Field, Message: v.Description})
+			}
+		case *errdetails.RequestInfo:
+			p.RequestID = d.RequestId
+		}
+	}
+	return p
+}
+
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(r, err)
+	w.Header().Set("Content-Type", MIMEProblem)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// AbortWithProblem 結束 gin 的 handler chain 並回傳 problem+json，轉換後的 status 也記在 c.Errors
+func AbortWithProblem(c *gin.Context, err error) {
+	err = ToStatus(c.Request.Context(), err).Err()
+	_ = c.Error(err)
+	c.Abort()
+	WriteProblem(c.Writer, c.Request, err)
+}
+
+// GatewayErrorHandler 讓 grpc-gateway 的錯誤也以 problem+json 回傳
+func GatewayErrorHandler(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	WriteProblem(w, r, err)
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"slices"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// typeCertificate 標示身分來自 mTLS 的 client 憑證而不是 token
+const typeCertificate = "certificate"
+
+// CertificateClaims 把通過驗證的 client 憑證當成身分，和 Kubernetes 的慣例相同：
+// CN 是名稱，O 包含 admin 代表管理員，其他都是一般使用者。
+// 憑證不對應到資料庫裡的使用者，所以 UserID 為 0，OwnerOrAdmin 的規則只有管理員能通過
+func CertificateClaims(cert *x509.Certificate) *Claims {
+	role := models.RoleUser
+	if slices.Contains(cert.Subject.Organization, models.RoleAdmin) {
+		role = models.RoleAdmin
+	}
+	return &Claims{
+		Role:             role,
+		TokenType:        typeCertificate,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: cert.Subject.CommonName},
+	}
+}
+
+// peerCertificate 取出 gRPC 連線上已驗證的 client 憑證，沒有 mTLS 時回傳 nil
+func peerCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	return verifiedLeaf(info.State.VerifiedChains)
+}
+
+// requestCertificate 是 HTTP 請求的 peerCertificate
+func requestCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil {
+		return nil
+	}
+	return verifiedLeaf(r.TLS.VerifiedChains)
+}
+
+// verifiedLeaf 只採用有通過 CA 驗證的憑證，PeerCertificates 可能是 client 自己帶的任意憑證
+func verifiedLeaf(chains [][]*x509.Certificate) *x509.Certificate {
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil
+	}
+	return chains[0][0]
+}
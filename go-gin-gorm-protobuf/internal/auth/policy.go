@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionalphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+type Rule int
+
+const (
+	// AdminOnly 是沒有列在 Rules 裡的 RPC 的預設規則
+	AdminOnly Rule = iota
+	Public
+	Authenticated
+	OwnerOrAdmin
+)
+
+var (
+	ErrUnauthenticated  = apperr.New(apperr.Unauthorized, "UNAUTHENTICATED", "missing access token")
+	ErrPermissionDenied = apperr.New(apperr.Forbidden, "PERMISSION_DENIED", "permission denied")
+)
+
+// Rules 是每個 RPC 的授權規則，gin middleware 與 gRPC interceptor 共用同一份
+var Rules = map[string]Rule{
+	pb.AuthService_Login_FullMethodName:   Public,
+	pb.AuthService_Refresh_FullMethodName: Public,
+
+	// k8s 與負載平衡器的 health check 不會帶 token
+	healthpb.Health_Check_FullMethodName: Public,
+	healthpb.Health_Watch_FullMethodName: Public,
+	// grpcurl 需要 reflection 才能列出 service
+	reflectionpb.ServerReflection_ServerReflectionInfo_FullMethodName:      Public,
+	reflectionalphapb.ServerReflection_ServerReflectionInfo_FullMethodName: Public,
+
+	pb.UserService_CreateUser_FullMethodName:  Public,
+	pb.UserService_GetUser_FullMethodName:     Authenticated,
+	pb.UserService_ListUsers_FullMethodName:   Authenticated,
+	pb.UserService_UpdateUser_FullMethodName:  OwnerOrAdmin,
+	pb.UserService_ReplaceUser_FullMethodName: OwnerOrAdmin,
+	pb.UserService_DeleteUser_FullMethodName:  OwnerOrAdmin,
+	// 已刪除的帳號無法登入，只有管理員可以復原
+	pb.UserService_RestoreUser_FullMethodName:     AdminOnly,
+	pb.UserService_ListUserHistory_FullMethodName: OwnerOrAdmin,
+	// 大量匯入與變更通知包含所有使用者，只開放給管理員
+	pb.UserService_ImportUsers_FullMethodName: AdminOnly,
+	pb.UserService_WatchUsers_FullMethodName:  AdminOnly,
+}
+
+// Authorize 檢查 claims 能不能對 targetID 這個使用者呼叫 fullMethod，claims 為 nil 代表沒有登入
+func Authorize(fullMethod string, claims *Claims, targetID uint) error {
+	rule := Rules[fullMethod]
+	if rule == Public {
+		return nil
+	}
+	if claims == nil {
+		return ErrUnauthenticated
+	}
+
+	switch {
+	case claims.Role == models.RoleAdmin:
+		return nil
+	case rule == Authenticated:
+		return nil
+	// 憑證的 UserID 是 0，不能當成 id 0 的擁有者 (id 無法解析時也是 0)
+	case rule == OwnerOrAdmin && claims.UserID != 0 && claims.UserID == targetID:
+		return nil
+	}
+	return ErrPermissionDenied
+}
+
+type claimsKey struct{}
+
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext 取出通過驗證的 claims，Public 的 RPC 可能沒有
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	typeAccess  = "access"
+	typeRefresh = "refresh"
+)
+
+var (
+	ErrInvalidToken = apperr.New(apperr.Unauthorized, "INVALID_TOKEN", "invalid or expired token")
+	ErrTokenReused  = apperr.New(apperr.Unauthorized, "TOKEN_REUSED", "refresh token already used")
+)
+
+type Claims struct {
+	UserID    uint   `json:"uid"`
+	Role      string `json:"role"`
+	TokenType string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// TokenManager 簽發與驗證 HS256 JWT。
+// refresh token 只能用一次，已發出且尚未使用的 jti 記在記憶體中，所以重啟後需要重新登入
+type TokenManager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+
+	mu      sync.Mutex
+	refresh map[string]time.Time // jti -> 到期時間
+}
+
+func NewTokenManager(secret []byte, accessTTL, refreshTTL time.Duration) *TokenManager {
+	return &TokenManager{
+		secret:     secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		refresh:    make(map[string]time.Time),
+	}
+}
+
+func (m *TokenManager) Issue(userID uint, role string) (*TokenPair, error) {
+	now := time.Now()
+
+	access, err := m.sign(userID, role, typeAccess, newID(), now, m.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	jti := newID()
+	refresh, err := m.sign(userID, role, typeRefresh, jti, now, m.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	for id, exp := range m.refresh {
+		if now.After(exp) {
+			delete(m.refresh, id)
+		}
+	}
+	m.refresh[jti] = now.Add(m.refreshTTL)
+	m.mu.Unlock()
+
+	return &TokenPair{AccessToken: access, RefreshToken: refresh, ExpiresIn: m.accessTTL}, nil
+}
+
+// ParseAccess 驗證 access token 並回傳 claims
+func (m *TokenManager) ParseAccess(token string) (*Claims, error) {
+	return m.parse(token, typeAccess)
+}
+
+// ConsumeRefresh 驗證 refresh token 並讓它失效，呼叫端再用最新的使用者資料 Issue 新的一組
+func (m *TokenManager) ConsumeRefresh(token string) (*Claims, error) {
+	claims, err := m.parse(token, typeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.refresh[claims.ID]; !ok {
+		return nil, ErrTokenReused
+	}
+	delete(m.refresh, claims.ID)
+	return claims, nil
+}
+
+func (m *TokenManager) sign(userID uint, role, tokenType, jti string, now time.Time, ttl time.Duration) (string, error) {
+	claims := Claims{
+		UserID:    userID,
+		Role:      role,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+}
+
+func (m *TokenManager) parse(token, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.TokenType != tokenType {
+		return nil, fmt.Errorf("%w: expected %s token", ErrInvalidToken, tokenType)
+	}
+	return claims, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -1,59 +0,0 @@
-package controllers
-
-import (
-	"github.com/gin-gonic/gin"
-	"github.com/go-gin-gorm-protobuf/internal/services"
-	"log/slog"
-	"net/http"
-	"strconv"
-)
-
-type UserController struct {
-	Service *services.UserService
-}
-
-func (uc *UserController) CreateUser(c *gin.Context) {
-	var request struct {
-		Name     string `json:"name"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	user, err := uc.Service.CreateUser(request.Name, request.Email, request.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, user)
-}
-
-func (uc *UserController) GetUser(c *gin.Context) {
-	slog.Error("GetUser")
-	users, err := uc.Service.GetUsers()
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, users)
-}
-
-func (uc *UserController) DeleteUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	result, err := uc.Service.DeleteUser(uint(id))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err})
-		return
-	}
-	c.JSON(http.StatusOK, result)
-}
@@ -0,0 +1,22 @@
+// Package grpcutil 放 stream interceptor 共用的小工具
+package grpcutil
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// WithContext 讓 stream 的 handler 拿到 interceptor 加工過的 ctx
+func WithContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
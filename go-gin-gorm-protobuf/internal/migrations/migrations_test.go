@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %q has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+	}
+}
+
+func TestLoadRequiresDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_create_users.up.sql": {Data: []byte("CREATE TABLE users ();")},
+	}
+	if _, err := load(fsys); err == nil {
+		t.Fatal("expected an error for a migration without a down file")
+	}
+}
@@ -0,0 +1,8 @@
+-- 與舊版 AutoMigrate 建出的 users 表相同，已存在時直接沿用
+CREATE TABLE IF NOT EXISTS users (
+    id       BIGSERIAL PRIMARY KEY,
+    name     VARCHAR(100) NOT NULL,
+    password VARCHAR(255),
+    email    VARCHAR(100) NOT NULL,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS user_audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL,
+    action     VARCHAR(20) NOT NULL,
+    actor_id   BIGINT,
+    before     JSONB,
+    after      JSONB,
+    request_id VARCHAR(64),
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_user_audit_log_user_id ON user_audit_log (user_id);
+
+-- 稽核紀錄只能新增
+CREATE OR REPLACE FUNCTION user_audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'user_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS user_audit_log_append_only ON user_audit_log;
+CREATE TRIGGER user_audit_log_append_only
+    BEFORE UPDATE OR DELETE ON user_audit_log
+    FOR EACH ROW EXECUTE FUNCTION user_audit_log_append_only();
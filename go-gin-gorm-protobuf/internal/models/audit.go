@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+// UserAuditLog 只會新增不會修改，Before/After 是不含密碼的使用者 JSON
+type UserAuditLog struct {
+	ID        uint    `gorm:"primary_key"`
+	UserID    uint    `gorm:"not null;index"`
+	Action    string  `gorm:"size:20;not null"`
+	ActorID   *uint   // nil 代表未登入，例如自行註冊
+	Before    *string `gorm:"type:jsonb"`
+	After     *string `gorm:"type:jsonb"`
+	RequestID string  `gorm:"size:64"`
+	CreatedAt time.Time
+}
+
+func (UserAuditLog) TableName() string {
+	return "user_audit_log"
+}
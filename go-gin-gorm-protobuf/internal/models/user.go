@@ -1,19 +1,34 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// PasswordCost 是 bcrypt 的 cost，測試時可以調成 bcrypt.MinCost 加快速度
+var PasswordCost = bcrypt.DefaultCost
+
+// User 為軟刪除，email 只需在未刪除的使用者之間唯一
 type User struct {
-	ID       uint   `gorm:"primary_key"`
-	Name     string `gorm:"size:100;not null"`
-	Password string `gorm:"size:255"`
-	Email    string `gorm:"size:100;unique;not null"`
+	ID        uint   `gorm:"primary_key"`
+	Name      string `gorm:"size:100;not null"`
+	Password  string `gorm:"size:255"`
+	Email     string `gorm:"size:100;not null;uniqueIndex:idx_users_email,where:deleted_at IS NULL"`
+	Role      string `gorm:"size:20;not null;default:user"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 func (u *User) SetPassword(password string) error {
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
 	if err != nil {
 		return err
 	}
@@ -25,11 +40,3 @@ func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
-
-func Migrate(db *gorm.DB) {
-	err := db.AutoMigrate(&User{})
-	if err != nil {
-		panic(err)
-		return
-	}
-}
@@ -0,0 +1,36 @@
+// Package openapi 從 protobuf descriptor 與 Go 型別產生 OpenAPI 3 的 schema，
+// 產生的文件由 ginswagger 提供
+package openapi
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+// GoSchema 用 reflection 產生 Go struct 的 schema 並放進 components，
+// json tag 沒有 omitempty 的欄位視為 required
+func GoSchema(components openapi3.Schemas, v interface{}) (*openapi3.SchemaRef, error) {
+	return openapi3gen.NewSchemaRefForValue(v, components,
+		openapi3gen.CreateComponentSchemas(openapi3gen.ExportComponentSchemasOptions{
+			ExportComponentSchemas: true,
+			ExportTopLevelSchema:   true,
+		}),
+		openapi3gen.SchemaCustomizer(requiredFields),
+	)
+}
+
+func requiredFields(_ string, t reflect.Type, _ reflect.StructTag, schema *openapi3.Schema) error {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
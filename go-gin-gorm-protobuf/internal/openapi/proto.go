@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Messages 把 protobuf message 轉成 components 裡的 schema。
+// JSON 的對應與 gateway 的 protojson 設定 (UseProtoNames) 一致：欄位使用 proto 名稱，
+// 64 位元整數是字串，enum 是名稱字串；(validate.field) 的規則會轉成對應的限制
+type Messages struct {
+	Components openapi3.Schemas
+}
+
+// Ref 回傳 md 的 schema reference，第一次遇到時才產生
+func (m Messages) Ref(md protoreflect.MessageDescriptor) *openapi3.SchemaRef {
+	if schema, ok := wellKnown(md.FullName()); ok {
+		return schema.NewRef()
+	}
+	name := schemaName(md)
+	if _, ok := m.Components[name]; !ok {
+		// 先佔位再產生欄位，message 互相參照時才不會無限遞迴
+		schema := openapi3.NewObjectSchema()
+		m.Components[name] = schema.NewRef()
+		*schema = *m.Object(md, nil)
+	}
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, m.Components[name].Value)
+}
+
+// Object 產生 md 的 object schema，略過 exclude 中的欄位 (例如已經放在路徑上的 id)
+func (m Messages) Object(md protoreflect.MessageDescriptor, exclude map[string]bool) *openapi3.Schema {
+	schema := openapi3.NewObjectSchema()
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := string(fd.Name())
+		if exclude[name] {
+			continue
+		}
+		schema.WithPropertyRef(name, m.Field(fd, false))
+		// optional 欄位沒帶代表不修改，只有一般欄位才是必填
+		if rules := Rules(fd); rules.GetRequired() && !fd.HasPresence() {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// Field 產生欄位的 schema。param 為 true 時是路徑或 query 參數，整數直接寫成數字
+func (m Messages) Field(fd protoreflect.FieldDescriptor, param bool) *openapi3.SchemaRef {
+	switch {
+	case fd.IsMap():
+		schema := openapi3.NewObjectSchema()
+		schema.AdditionalProperties = openapi3.AdditionalProperties{Schema: m.single(fd.MapValue(), param)}
+		return schema.NewRef()
+	case fd.IsList():
+		schema := openapi3.NewArraySchema()
+		schema.Items = m.single(fd, param)
+		return schema.NewRef()
+	}
+	return m.single(fd, param)
+}
+
+func (m Messages) single(fd protoreflect.FieldDescriptor, param bool) *openapi3.SchemaRef {
+	var schema *openapi3.Schema
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return m.Ref(fd.Message())
+	case protoreflect.EnumKind:
+		return m.enum(fd.Enum())
+	case protoreflect.BoolKind:
+		schema = openapi3.NewBoolSchema()
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		schema = openapi3.NewInt32Schema()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		schema = openapi3.NewInt64Schema().WithMin(0)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if param {
+			schema = openapi3.NewInt64Schema()
+		} else {
+			schema = openapi3.NewStringSchema().WithFormat("int64")
+		}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		schema = openapi3.NewFloat64Schema()
+	case protoreflect.BytesKind:
+		schema = openapi3.NewBytesSchema()
+	default:
+		schema = openapi3.NewStringSchema()
+	}
+	constrain(schema, Rules(fd))
+	return schema.NewRef()
+}
+
+func (m Messages) enum(ed protoreflect.EnumDescriptor) *openapi3.SchemaRef {
+	name := schemaName(ed)
+	if _, ok := m.Components[name]; !ok {
+		schema := openapi3.NewStringSchema()
+		values := ed.Values()
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+		m.Components[name] = schema.NewRef()
+	}
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, m.Components[name].Value)
+}
+
+// Rules 回傳欄位上的 (validate.field)，沒有設定時回傳 nil
+func Rules(fd protoreflect.FieldDescriptor) *pb.FieldRules {
+	rules, _ := proto.GetExtension(fd.Options(), pb.E_Field).(*pb.FieldRules)
+	return rules
+}
+
+func constrain(schema *openapi3.Schema, rules *pb.FieldRules) {
+	if rules == nil {
+		return
+	}
+	if rules.Required && schema.Type.Is(openapi3.TypeString) {
+		schema.MinLength = 1
+	}
+	if rules.MinLen > 0 {
+		schema.MinLength = uint64(rules.MinLen)
+	}
+	if rules.MaxLen > 0 {
+		schema.WithMaxLength(int64(rules.MaxLen))
+	}
+	if rules.Email {
+		schema.Format = "email"
+	}
+	if rules.Positive {
+		schema.WithMin(1)
+	}
+	if p := rules.Password; p != nil {
+		if p.MinLen > 0 {
+			schema.MinLength = uint64(p.MinLen)
+		}
+		var must []string
+		if p.RequireLetter {
+			must = append(must, "a letter")
+		}
+		if p.RequireDigit {
+			must = append(must, "a digit")
+		}
+		if p.RequireSymbol {
+			must = append(must, "a symbol")
+		}
+		if len(must) > 0 {
+			schema.Description = "Must contain " + strings.Join(must, ", ") + "."
+		}
+		if p.MaxBytes > 0 {
+			schema.Description = strings.TrimSpace(schema.Description + fmt.Sprintf(" At most %d bytes.", p.MaxBytes))
+		}
+	}
+}
+
+// wellKnown 是 protojson 有特殊 JSON 格式的型別
+func wellKnown(name protoreflect.FullName) (*openapi3.Schema, bool) {
+	switch name {
+	case "google.protobuf.Timestamp":
+		return openapi3.NewDateTimeSchema(), true
+	case "google.protobuf.Duration":
+		return openapi3.NewStringSchema().WithPattern(`^-?[0-9]+(\.[0-9]+)?s$`), true
+	case "google.protobuf.FieldMask":
+		return openapi3.NewStringSchema(), true
+	case "google.protobuf.Struct":
+		return openapi3.NewObjectSchema().WithAnyAdditionalProperties(), true
+	case "google.protobuf.Value":
+		return openapi3.NewSchema(), true
+	case "google.protobuf.Empty":
+		return openapi3.NewObjectSchema(), true
+	case "google.protobuf.StringValue":
+		return openapi3.NewStringSchema().WithNullable(), true
+	case "google.protobuf.BoolValue":
+		return openapi3.NewBoolSchema().WithNullable(), true
+	case "google.protobuf.Int32Value":
+		return openapi3.NewInt32Schema().WithNullable(), true
+	}
+	return nil, false
+}
+
+// schemaName 去掉 package 名稱，巢狀型別保留外層名稱，例如 UserEvent.Type
+func schemaName(d protoreflect.Descriptor) string {
+	return strings.TrimPrefix(string(d.FullName()), string(d.ParentFile().Package())+".")
+}
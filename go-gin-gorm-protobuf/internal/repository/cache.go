@@ -0,0 +1,372 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_cache_lookups_total",
+		Help: "User cache lookups by kind (user, list) and result (hit, negative_hit, miss, bypass, error).",
+	}, []string{"kind", "result"})
+
+	cacheDegraded = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "user_cache_degraded",
+		Help: "1 while Redis is unreachable or the cache may hold stale entries and reads go to the database.",
+	})
+)
+
+// negativeEntry 代表資料庫裡沒有這個使用者
+const negativeEntry = "-"
+
+type CacheOptions struct {
+	UserTTL     time.Duration // 單一使用者，預設 5 分鐘
+	ListTTL     time.Duration // 列表，任何寫入都會讓所有列表失效，預設 1 分鐘
+	NegativeTTL time.Duration // 不存在的使用者，預設 30 秒
+	// RetryInterval 是 Redis 出錯後多久再試一次，這段期間直接讀資料庫，預設 5 秒
+	RetryInterval time.Duration
+	Prefix        string       // key 的前綴，預設 "users:"
+	Logger        *slog.Logger // nil 時使用 slog.Default()
+}
+
+// CachedUserRepository 在 UserRepository 前面加上 Redis read-through 快取，快取 Get 與 List。
+// 寫入成功後刪除該使用者的 key 並遞增列表的版本，讓所有列表一起失效。
+// 同一個 key 同時只會有一個請求讀資料庫 (singleflight)，不存在的 id 也會快取 NegativeTTL。
+//
+// Redis 出錯時不影響請求，直接讀寫資料庫。失效沒有送到 Redis 時，快取的內容可能是舊的，
+// 所以之後最長的 TTL 內都不讀快取，只寫入從資料庫讀到的新資料。
+// 讀取與寫入同時發生時，快取可能留下寫入前的資料，最多保留 UserTTL。
+// 快取的使用者不含密碼雜湊，FindByEmail (登入) 不經過快取
+type CachedUserRepository struct {
+	next  UserRepository
+	redis redis.UniversalClient
+	opts  CacheOptions
+	group singleflight.Group
+
+	mu         sync.Mutex
+	downUntil  time.Time // 在這之前不使用 Redis
+	staleUntil time.Time // 在這之前不讀快取
+}
+
+func NewCachedUserRepository(next UserRepository, client redis.UniversalClient, opts CacheOptions) *CachedUserRepository {
+	if opts.UserTTL <= 0 {
+		opts.UserTTL = 5 * time.Minute
+	}
+	if opts.ListTTL <= 0 {
+		opts.ListTTL = time.Minute
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = 30 * time.Second
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 5 * time.Second
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "users:"
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &CachedUserRepository{next: next, redis: client, opts: opts}
+}
+
+// cachedUser 是存進 Redis 的內容，刻意不含密碼雜湊
+type cachedUser struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toCached(user *models.User) cachedUser {
+	return cachedUser{user.ID, user.Name, user.Email, user.Role, user.CreatedAt, user.UpdatedAt}
+}
+
+func (u cachedUser) model() models.User {
+	return models.User{ID: u.ID, Name: u.Name, Email: u.Email, Role: u.Role, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt}
+}
+
+func (c *CachedUserRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	key := c.opts.Prefix + "id:" + strconv.FormatUint(uint64(id), 10)
+	v, err := c.load(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return c.loadUser(ctx, key, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 同一次 singleflight 的呼叫者共用結果，各自回傳一份複本
+	user := *v.(*models.User)
+	return &user, nil
+}
+
+func (c *CachedUserRepository) List(ctx context.Context, query UserQuery) ([]models.User, error) {
+	data, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:16])
+
+	// 列表的 key 帶著版本，寫入時遞增版本就等於清掉所有列表
+	version := "none"
+	if c.trusted() {
+		n, err := c.redis.Get(ctx, c.listVersionKey()).Int64()
+		switch {
+		case err == nil || errors.Is(err, redis.Nil):
+			version = strconv.FormatInt(n, 10)
+		default:
+			c.failed(err)
+		}
+	}
+	key := c.opts.Prefix + "list:" + version + ":" + hash
+
+	v, err := c.load(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return c.loadList(ctx, key, version != "none", query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.User(nil), v.([]models.User)...), nil
+}
+
+// load 以 singleflight 執行 fn，fn 不受單一呼叫者取消的影響，其他等待的呼叫者還是拿得到結果
+func (c *CachedUserRepository) load(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	detached := context.WithoutCancel(ctx)
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		return fn(detached)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		return res.Val, res.Err
+	}
+}
+
+func (c *CachedUserRepository) loadUser(ctx context.Context, key string, id uint) (*models.User, error) {
+	if c.trusted() {
+		data, err := c.redis.Get(ctx, key).Bytes()
+		switch {
+		case err == nil && string(data) == negativeEntry:
+			cacheLookups.WithLabelValues("user", "negative_hit").Inc()
+			return nil, ErrUserNotFound
+		case err == nil:
+			var cached cachedUser
+			if err := json.Unmarshal(data, &cached); err == nil {
+				cacheLookups.WithLabelValues("user", "hit").Inc()
+				user := cached.model()
+				return &user, nil
+			}
+			cacheLookups.WithLabelValues("user", "miss").Inc()
+		case errors.Is(err, redis.Nil):
+			cacheLookups.WithLabelValues("user", "miss").Inc()
+		default:
+			cacheLookups.WithLabelValues("user", "error").Inc()
+			c.failed(err)
+		}
+	} else {
+		cacheLookups.WithLabelValues("user", "bypass").Inc()
+	}
+
+	user, err := c.next.Get(ctx, id)
+	if errors.Is(err, ErrUserNotFound) {
+		c.store(ctx, key, negativeEntry, c.opts.NegativeTTL)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(toCached(user)); err == nil {
+		c.store(ctx, key, data, c.opts.UserTTL)
+	}
+	return user, nil
+}
+
+func (c *CachedUserRepository) loadList(ctx context.Context, key string, versioned bool, query UserQuery) ([]models.User, error) {
+	if versioned {
+		data, err := c.redis.Get(ctx, key).Bytes()
+		switch {
+		case err == nil:
+			var cached []cachedUser
+			if err := json.Unmarshal(data, &cached); err == nil {
+				cacheLookups.WithLabelValues("list", "hit").Inc()
+				users := make([]models.User, len(cached))
+				for i := range cached {
+					users[i] = cached[i].model()
+				}
+				return users, nil
+			}
+			cacheLookups.WithLabelValues("list", "miss").Inc()
+		case errors.Is(err, redis.Nil):
+			cacheLookups.WithLabelValues("list", "miss").Inc()
+		default:
+			cacheLookups.WithLabelValues("list", "error").Inc()
+			c.failed(err)
+		}
+	} else {
+		cacheLookups.WithLabelValues("list", "bypass").Inc()
+	}
+
+	users, err := c.next.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// 不知道目前的版本時不能寫入，否則之後的寫入無法讓它失效
+	if versioned {
+		cached := make([]cachedUser, len(users))
+		for i := range users {
+			cached[i] = toCached(&users[i])
+		}
+		if data, err := json.Marshal(cached); err == nil {
+			c.store(ctx, key, data, c.opts.ListTTL)
+		}
+	}
+	return users, nil
+}
+
+func (c *CachedUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return c.next.FindByEmail(ctx, email)
+}
+
+func (c *CachedUserRepository) History(ctx context.Context, id uint) ([]models.UserAuditLog, error) {
+	return c.next.History(ctx, id)
+}
+
+func (c *CachedUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := c.next.Create(ctx, user); err != nil {
+		return err
+	}
+	// 先前查過這個 id 可能留下 negative 快取
+	c.invalidate(ctx, user.ID)
+	return nil
+}
+
+func (c *CachedUserRepository) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs, err := c.next.CreateMany(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for i, user := range users {
+		if errs[i] == nil {
+			ids = append(ids, user.ID)
+		}
+	}
+	c.invalidate(ctx, ids...)
+	return errs, nil
+}
+
+func (c *CachedUserRepository) Update(ctx context.Context, id uint, apply func(*models.User) error) (*models.User, error) {
+	user, err := c.next.Update(ctx, id, apply)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, id)
+	return user, nil
+}
+
+func (c *CachedUserRepository) Delete(ctx context.Context, id uint) error {
+	if err := c.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	c.invalidate(ctx, id)
+	return nil
+}
+
+func (c *CachedUserRepository) Restore(ctx context.Context, id uint) (*models.User, error) {
+	user, err := c.next.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidate(ctx, id)
+	return user, nil
+}
+
+// invalidate 刪除 ids 的快取並讓所有列表失效。資料庫已經寫入了，所以不理會 ctx 的取消
+func (c *CachedUserRepository) invalidate(ctx context.Context, ids ...uint) {
+	if !c.available() {
+		c.markStale()
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	pipe := c.redis.TxPipeline()
+	for _, id := range ids {
+		pipe.Del(ctx, c.opts.Prefix+"id:"+strconv.FormatUint(uint64(id), 10))
+	}
+	pipe.Incr(ctx, c.listVersionKey())
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.failed(err)
+		c.markStale()
+	}
+}
+
+func (c *CachedUserRepository) store(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if !c.available() {
+		return
+	}
+	if err := c.redis.Set(ctx, key, value, ttl).Err(); err != nil {
+		c.failed(err)
+	}
+}
+
+func (c *CachedUserRepository) listVersionKey() string {
+	return c.opts.Prefix + "list:version"
+}
+
+// available 回傳是否可以使用 Redis
+func (c *CachedUserRepository) available() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().After(c.downUntil)
+}
+
+// trusted 回傳快取的內容是否可以直接使用
+func (c *CachedUserRepository) trusted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	trusted := now.After(c.downUntil) && now.After(c.staleUntil)
+	if trusted {
+		cacheDegraded.Set(0)
+	}
+	return trusted
+}
+
+// failed 在 Redis 出錯後暫停使用 RetryInterval，只在剛出錯時記錄一次
+func (c *CachedUserRepository) failed(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.After(c.downUntil) {
+		c.opts.Logger.Warn("user cache unavailable, reading from the database",
+			slog.Any("error", err), slog.Duration("retry_in", c.opts.RetryInterval))
+	}
+	c.downUntil = now.Add(c.opts.RetryInterval)
+	cacheDegraded.Set(1)
+}
+
+// markStale 在失效沒有送到 Redis 時呼叫，所有快取最晚在最長的 TTL 後過期
+func (c *CachedUserRepository) markStale() {
+	ttl := max(c.opts.UserTTL, c.opts.ListTTL, c.opts.NegativeTTL)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.staleUntil = time.Now().Add(ttl)
+	cacheDegraded.Set(1)
+}
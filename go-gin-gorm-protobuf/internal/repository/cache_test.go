@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+// countingRepo 記錄實際讀到資料庫的次數，block 不為 nil 時 Get 會等它關閉
+type countingRepo struct {
+	UserRepository
+	gets, lists atomic.Int32
+	block       chan struct{}
+}
+
+func (r *countingRepo) Get(ctx context.Context, id uint) (*models.User, error) {
+	r.gets.Add(1)
+	if r.block != nil {
+		<-r.block
+	}
+	return r.UserRepository.Get(ctx, id)
+}
+
+func (r *countingRepo) List(ctx context.Context, query UserQuery) ([]models.User, error) {
+	r.lists.Add(1)
+	return r.UserRepository.List(ctx, query)
+}
+
+func newCached(t *testing.T) (*CachedUserRepository, *countingRepo, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	db := &countingRepo{UserRepository: NewMemoryUserRepository()}
+	cache := NewCachedUserRepository(db, client, CacheOptions{
+		RetryInterval: 10 * time.Millisecond,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	return cache, db, mr
+}
+
+func createUser(t *testing.T, repo UserRepository, name string) *models.User {
+	t.Helper()
+	user := &models.User{Name: name, Email: strings.ToLower(name) + "@example.com", Password: "$2a$hash"}
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestCacheReadThrough(t *testing.T) {
+	cache, db, mr := newCached(t)
+	ctx := context.Background()
+	alice := createUser(t, cache, "Alice")
+
+	hits := testutil.ToFloat64(cacheLookups.WithLabelValues("user", "hit"))
+	for i := 0; i < 3; i++ {
+		user, err := cache.Get(ctx, alice.ID)
+		if err != nil || user.Name != "Alice" {
+			t.Fatalf("Get = %v, %v", user, err)
+		}
+	}
+	if n := db.gets.Load(); n != 1 {
+		t.Errorf("database read %d times, want 1", n)
+	}
+	if got := testutil.ToFloat64(cacheLookups.WithLabelValues("user", "hit")) - hits; got != 2 {
+		t.Errorf("hits = %v, want 2", got)
+	}
+	if ttl := mr.TTL("users:id:1"); ttl != 5*time.Minute {
+		t.Errorf("user TTL = %v", ttl)
+	}
+	if data, _ := mr.Get("users:id:1"); strings.Contains(data, "$2a$") {
+		t.Errorf("password hash was cached: %s", data)
+	}
+
+	// 修改後讀到新的資料
+	if _, err := cache.Update(ctx, alice.ID, func(u *models.User) error { u.Name = "Alicia"; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := cache.Get(ctx, alice.ID); user.Name != "Alicia" {
+		t.Errorf("Get after Update = %q", user.Name)
+	}
+
+	// 刪除後 Get 回傳 ErrUserNotFound，之後的查詢由 negative 快取回答
+	if err := cache.Delete(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	before := db.gets.Load()
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Get(ctx, alice.ID); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("Get after Delete: %v", err)
+		}
+	}
+	if n := db.gets.Load() - before; n != 1 {
+		t.Errorf("database read %d times for a missing user, want 1", n)
+	}
+	if ttl := mr.TTL("users:id:1"); ttl != 30*time.Second {
+		t.Errorf("negative TTL = %v", ttl)
+	}
+	if _, err := cache.Restore(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Get(ctx, alice.ID); err != nil {
+		t.Errorf("Get after Restore: %v", err)
+	}
+}
+
+func TestCacheNegativeEntryClearedOnCreate(t *testing.T) {
+	cache, _, _ := newCached(t)
+	ctx := context.Background()
+
+	// 查過還不存在的 id 2，建立的下一個使用者就是 2
+	createUser(t, cache, "Alice")
+	if _, err := cache.Get(ctx, 2); !errors.Is(err, ErrUserNotFound) {
+		t.Fatal(err)
+	}
+	bob := createUser(t, cache, "Bob")
+	if user, err := cache.Get(ctx, bob.ID); err != nil || user.Name != "Bob" {
+		t.Errorf("Get(%d) = %v, %v", bob.ID, user, err)
+	}
+}
+
+func TestCacheList(t *testing.T) {
+	cache, db, _ := newCached(t)
+	ctx := context.Background()
+	createUser(t, cache, "Alice")
+
+	query := UserQuery{SortBy: "name", Limit: 10}
+	for i := 0; i < 2; i++ {
+		if users, err := cache.List(ctx, query); err != nil || len(users) != 1 {
+			t.Fatalf("List = %v, %v", users, err)
+		}
+	}
+	if _, err := cache.List(ctx, UserQuery{SortBy: "name", Desc: true, Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.lists.Load(); n != 2 {
+		t.Errorf("database listed %d times, want 2 (one per distinct query)", n)
+	}
+
+	// 任何寫入都讓列表失效
+	createUser(t, cache, "Bob")
+	if users, _ := cache.List(ctx, query); len(users) != 2 {
+		t.Errorf("List after Create returned %d users", len(users))
+	}
+}
+
+func TestCacheSingleflight(t *testing.T) {
+	cache, db, _ := newCached(t)
+	ctx := context.Background()
+	alice := createUser(t, cache, "Alice")
+	db.block = make(chan struct{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Get(ctx, alice.ID)
+			errs <- err
+		}()
+	}
+	// 等第一個請求進到資料庫，其他請求都在等它
+	for db.gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(db.block)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := db.gets.Load(); n != 1 {
+		t.Errorf("database read %d times, want 1", n)
+	}
+}
+
+func TestCacheDegraded(t *testing.T) {
+	cache, db, mr := newCached(t)
+	ctx := context.Background()
+	alice := createUser(t, cache, "Alice")
+	if _, err := cache.Get(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Redis 掛掉時照常服務，修改的失效送不出去
+	mr.Close()
+	if user, err := cache.Get(ctx, alice.ID); err != nil || user.Name != "Alice" {
+		t.Fatalf("Get while Redis is down = %v, %v", user, err)
+	}
+	if _, err := cache.Update(ctx, alice.ID, func(u *models.User) error { u.Name = "Alicia"; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if testutil.ToFloat64(cacheDegraded) != 1 {
+		t.Error("degraded gauge is not set")
+	}
+
+	// Redis 回來後，快取裡還是舊的 Alice，不能再讀它
+	if err := mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	before := db.gets.Load()
+	if user, err := cache.Get(ctx, alice.ID); err != nil || user.Name != "Alicia" {
+		t.Fatalf("Get after Redis recovered = %v, %v", user, err)
+	}
+	if db.gets.Load() == before {
+		t.Error("stale cache was read after a lost invalidation")
+	}
+	// 從資料庫讀到的新資料會寫回快取
+	if data, _ := mr.Get("users:id:1"); !strings.Contains(data, "Alicia") {
+		t.Errorf("cache was not refreshed: %s", data)
+	}
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormUserRepository 把使用者存在 PostgreSQL，DB 需要開啟 TranslateError
+type GormUserRepository struct {
+	DB *gorm.DB
+}
+
+func (r *GormUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return translateError(err, user.Email)
+		}
+		return writeAudit(ctx, tx, models.AuditCreate, user.ID, nil, user)
+	})
+}
+
+func (r *GormUserRepository) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	errs := make([]error, len(users))
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, user := range users {
+			// PostgreSQL 的 transaction 出錯後就不能繼續，所以每一筆用 savepoint 隔開
+			if err := tx.SavePoint("import_row").Error; err != nil {
+				return err
+			}
+			err := tx.Create(user).Error
+			if err != nil {
+				err = translateError(err, user.Email)
+			} else {
+				err = writeAudit(ctx, tx, models.AuditCreate, user.ID, nil, user)
+			}
+			if err != nil {
+				if err := tx.RollbackTo("import_row").Error; err != nil {
+					return err
+				}
+				user.ID = 0
+				errs[i] = err
+			}
+			// ROLLBACK TO 之後 savepoint 仍然存在，不論成功與否都要釋放，不然每一筆都會多疊一層
+			if err := tx.Exec("RELEASE SAVEPOINT import_row").Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+func (r *GormUserRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	return findUser(r.DB.WithContext(ctx), id)
+}
+
+func (r *GormUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+
+	err := r.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("user with email %q: %w", email, ErrUserNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) List(ctx context.Context, query UserQuery) ([]models.User, error) {
+	db := r.DB.WithContext(ctx).Model(&models.User{})
+	if query.NamePrefix != "" {
+		db = db.Where(`name LIKE ? ESCAPE '\'`, likePrefix(query.NamePrefix))
+	}
+	if query.EmailPrefix != "" {
+		db = db.Where(`email LIKE ? ESCAPE '\'`, likePrefix(query.EmailPrefix))
+	}
+	if query.After != nil {
+		db = seek(db, query)
+	}
+
+	var users []models.User
+	err := orderUsers(db, query).Limit(query.Limit).Find(&users).Error
+	return users, err
+}
+
+func (r *GormUserRepository) Update(ctx context.Context, id uint, apply func(*models.User) error) (*models.User, error) {
+	var user *models.User
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = lockUser(tx, id)
+		if err != nil {
+			return err
+		}
+		before := *user
+
+		if err := apply(user); err != nil {
+			return err
+		}
+		if err := tx.Save(user).Error; err != nil {
+			return translateError(err, user.Email)
+		}
+		return writeAudit(ctx, tx, models.AuditUpdate, id, &before, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *GormUserRepository) Delete(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, id)
+		if err != nil {
+			return err
+		}
+		before := *user
+
+		result := tx.Delete(user)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 檢查是否真的刪除了一筆資料，沒有的話代表已經被別的請求刪掉了
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("user with id %d: %w", id, ErrUserNotFound)
+		}
+
+		return writeAudit(ctx, tx, models.AuditDelete, id, &before, nil)
+	})
+}
+
+// Restore 若 email 已被其他人使用則回傳 ErrEmailTaken
+func (r *GormUserRepository) Restore(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("deleted user with id %d: %w", id, ErrUserNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		before := user
+
+		if err := tx.Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+			return translateError(err, user.Email)
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+
+		return writeAudit(ctx, tx, models.AuditRestore, id, &before, &user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) History(ctx context.Context, id uint) ([]models.UserAuditLog, error) {
+	db := r.DB.WithContext(ctx)
+
+	var count int64
+	if err := db.Unscoped().Model(&models.User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("user with id %d: %w", id, ErrUserNotFound)
+	}
+
+	var logs []models.UserAuditLog
+	err := db.Where("user_id = ?", id).Order("id").Find(&logs).Error
+	return logs, err
+}
+
+func findUser(db *gorm.DB, id uint) (*models.User, error) {
+	var user models.User
+
+	result := db.First(&user, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user with id %d: %w", id, ErrUserNotFound)
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+// lockUser 與 findUser 相同，但用 SELECT ... FOR UPDATE 鎖住這一列直到 transaction 結束。
+// READ COMMITTED 下兩個同時的 Update 會讀到同一份舊資料，後寫入的會蓋掉先寫入的修改，
+// 鎖住之後第二個請求會等第一個 commit 再讀到新的資料
+func lockUser(tx *gorm.DB, id uint) (*models.User, error) {
+	return findUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+// writeAudit 在同一個 transaction 裡寫入稽核紀錄
+func writeAudit(ctx context.Context, tx *gorm.DB, action string, userID uint, before, after *models.User) error {
+	log, err := newAuditLog(ctx, action, userID, before, after)
+	if err != nil {
+		return err
+	}
+	return tx.Create(log).Error
+}
+
+// translateError 把 email 的 unique index 衝突轉成 ErrEmailTaken
+func translateError(err error, email string) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%q: %w", email, ErrEmailTaken)
+	}
+	return err
+}
+
+// seek 只取排在 query.After 之後的資料，欄位名稱一定來自 SortColumns
+func seek(db *gorm.DB, query UserQuery) *gorm.DB {
+	op := ">"
+	if query.Desc {
+		op = "<"
+	}
+	column, after := query.SortBy, query.After
+	if SortColumns[column] == nil {
+		return db.Where("id "+op+" ?", after.ID)
+	}
+	return db.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op), after.Value, after.Value, after.ID)
+}
+
+func orderUsers(db *gorm.DB, query UserQuery) *gorm.DB {
+	order := " ASC"
+	if query.Desc {
+		order = " DESC"
+	}
+	if SortColumns[query.SortBy] != nil {
+		db = db.Order(query.SortBy + order)
+	}
+	return db.Order("id" + order)
+}
+
+// likePrefix 跳脫 LIKE 的萬用字元，只比對開頭
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"gorm.io/gorm"
+)
+
+// MemoryUserRepository 把使用者存在記憶體中，可以同時被多個 goroutine 使用，主要給測試用。
+// 字串排序是依 byte 比較，與資料庫的 collation 不一定相同
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]*models.User
+	audit  []models.UserAuditLog
+	lastID uint
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[uint]*models.User)}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(ctx, user)
+}
+
+func (r *MemoryUserRepository) CreateMany(ctx context.Context, users []*models.User) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make([]error, len(users))
+	for i, user := range users {
+		errs[i] = r.create(ctx, user)
+	}
+	return errs, nil
+}
+
+// create 呼叫前必須持有寫入鎖
+func (r *MemoryUserRepository) create(ctx context.Context, user *models.User) error {
+	if r.emailTaken(user.Email, 0) {
+		return fmt.Errorf("%q: %w", user.Email, ErrEmailTaken)
+	}
+	stored := *user
+	r.lastID++
+	stored.ID = r.lastID
+	stored.CreatedAt = time.Now()
+	stored.UpdatedAt = stored.CreatedAt
+	if stored.Role == "" {
+		stored.Role = models.RoleUser
+	}
+
+	if err := r.writeAudit(ctx, models.AuditCreate, stored.ID, nil, &stored); err != nil {
+		return err
+	}
+	r.users[stored.ID] = &stored
+	*user = stored
+	return nil
+}
+
+func (r *MemoryUserRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return nil, fmt.Errorf("user with id %d: %w", id, ErrUserNotFound)
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email && !user.DeletedAt.Valid {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("user with email %q: %w", email, ErrUserNotFound)
+}
+
+func (r *MemoryUserRepository) List(ctx context.Context, query UserQuery) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []models.User
+	for _, user := range r.users {
+		if !user.DeletedAt.Valid && query.matches(user) {
+			users = append(users, *user)
+		}
+	}
+	slices.SortFunc(users, func(a, b models.User) int {
+		return query.compare(&a, &Position{Value: sortValue(query.SortBy, &b), ID: b.ID})
+	})
+	if query.Limit > 0 && len(users) > query.Limit {
+		users = users[:query.Limit]
+	}
+	return users, nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, id uint, apply func(*models.User) error) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || stored.DeletedAt.Valid {
+		return nil, fmt.Errorf("user with id %d: %w", id, ErrUserNotFound)
+	}
+	before := *stored
+	user := *stored
+	if err := apply(&user); err != nil {
+		return nil, err
+	}
+	if r.emailTaken(user.Email, id) {
+		return nil, fmt.Errorf("%q: %w", user.Email, ErrEmailTaken)
+	}
+	user.UpdatedAt = time.Now()
+
+	if err := r.writeAudit(ctx, models.AuditUpdate, id, &before, &user); err != nil {
+		return nil, err
+	}
+	*stored = user
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || stored.DeletedAt.Valid {
+		return fmt.Errorf("user with id %d: %w", id, ErrUserNotFound)
+	}
+	if err := r.writeAudit(ctx, models.AuditDelete, id, stored, nil); err != nil {
+		return err
+	}
+	stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (r *MemoryUserRepository) Restore(ctx context.Context, id uint) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || !stored.DeletedAt.Valid {
+		return nil, fmt.Errorf("deleted user with id %d: %w", id, ErrUserNotFound)
+	}
+	if r.emailTaken(stored.Email, id) {
+		return nil, fmt.Errorf("%q: %w", stored.Email, ErrEmailTaken)
+	}
+	before := *stored
+	user := *stored
+	user.DeletedAt = gorm.DeletedAt{}
+
+	if err := r.writeAudit(ctx, models.AuditRestore, id, &before, &user); err != nil {
+		return nil, err
+	}
+	*stored = user
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) History(ctx context.Context, id uint) ([]models.UserAuditLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.users[id]; !ok {
+		return nil, fmt.Errorf("user with id %d: %w", id, ErrUserNotFound)
+	}
+	var logs []models.UserAuditLog
+	for _, log := range r.audit {
+		if log.UserID == id {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+// emailTaken 與 idx_users_email 相同，只檢查未刪除的其他使用者
+func (r *MemoryUserRepository) emailTaken(email string, except uint) bool {
+	for id, user := range r.users {
+		if id != except && user.Email == email && !user.DeletedAt.Valid {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAudit 呼叫前必須持有寫入鎖
+func (r *MemoryUserRepository) writeAudit(ctx context.Context, action string, userID uint, before, after *models.User) error {
+	log, err := newAuditLog(ctx, action, userID, before, after)
+	if err != nil {
+		return err
+	}
+	log.ID = uint(len(r.audit) + 1)
+	log.CreatedAt = time.Now()
+	r.audit = append(r.audit, *log)
+	return nil
+}
+
+func sortValue(column string, user *models.User) string {
+	if value := SortColumns[column]; value != nil {
+		return value(user)
+	}
+	return ""
+}
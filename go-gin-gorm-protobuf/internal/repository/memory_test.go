@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+)
+
+func TestMemoryConcurrentCreate(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// 每個 email 各有兩個 goroutine 搶著建立，只能成功一次
+			user := models.User{Name: "user", Email: fmt.Sprintf("user%d@example.com", i/2)}
+			errs <- repo.Create(ctx, &user)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	var created, taken int
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, ErrEmailTaken):
+			taken++
+		default:
+			t.Fatal(err)
+		}
+	}
+	if created != 50 || taken != 50 {
+		t.Errorf("created %d, taken %d, want 50 each", created, taken)
+	}
+
+	users, err := repo.List(ctx, UserQuery{SortBy: "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, user := range users {
+		if user.ID != uint(i+1) {
+			t.Fatalf("users[%d].ID = %d, want %d", i, user.ID, i+1)
+		}
+	}
+}
+
+func TestMemoryList(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+	for _, name := range []string{"bob", "alice", "bob", "carol"} {
+		user := models.User{Name: name, Email: fmt.Sprintf("%s%d@example.com", name, len(repo.users))}
+		if err := repo.Create(ctx, &user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Delete(ctx, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query UserQuery
+		want  []uint
+	}{
+		{"by id", UserQuery{SortBy: "id"}, []uint{1, 2, 3}},
+		{"by name", UserQuery{SortBy: "name"}, []uint{2, 1, 3}},
+		{"by name desc", UserQuery{SortBy: "name", Desc: true}, []uint{3, 1, 2}},
+		{"after position", UserQuery{SortBy: "name", After: &Position{Value: "bob", ID: 1}}, []uint{3}},
+		{"prefix", UserQuery{SortBy: "id", NamePrefix: "b"}, []uint{1, 3}},
+		{"limit", UserQuery{SortBy: "id", Limit: 2}, []uint{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := repo.List(ctx, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []uint
+			for _, user := range users {
+				got = append(got, user.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/go-gin-gorm-protobuf/internal/requestid"
+)
+
+var (
+	ErrUserNotFound = apperr.New(apperr.NotFound, "USER_NOT_FOUND", "user not found")
+	ErrEmailTaken   = apperr.New(apperr.Conflict, "EMAIL_TAKEN", "email already in use")
+)
+
+// UserRepository 是使用者的儲存層，services 只依賴這個介面，測試時可以換成 MemoryUserRepository。
+// 會修改資料的方法都要在同一個 transaction 裡寫入稽核紀錄，執行者與 request ID 取自 ctx
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	// CreateMany 在同一個 transaction 中建立多個使用者，每一筆各自成功或失敗，
+	// 回傳與 users 對應的錯誤 (nil 代表成功)，整批失敗時才回傳 error
+	CreateMany(ctx context.Context, users []*models.User) ([]error, error)
+	// Get 與 FindByEmail 只找未刪除的使用者，找不到時回傳 ErrUserNotFound
+	Get(ctx context.Context, id uint) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	List(ctx context.Context, query UserQuery) ([]models.User, error)
+	// Update 讀出使用者交給 apply 修改後存回，整個過程是原子的；同一個使用者的 Update 與 Delete 會依序執行
+	Update(ctx context.Context, id uint, apply func(*models.User) error) (*models.User, error)
+	// Delete 為軟刪除，Restore 復原被軟刪除的使用者
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) (*models.User, error)
+	// History 依時間順序回傳稽核紀錄，已刪除的使用者也查得到
+	History(ctx context.Context, id uint) ([]models.UserAuditLog, error)
+}
+
+// SortColumns 是允許排序的欄位與取值方式，同值時一律再用 id 排序讓分頁穩定
+var SortColumns = map[string]func(*models.User) string{
+	"id":    nil,
+	"name":  func(u *models.User) string { return u.Name },
+	"email": func(u *models.User) string { return u.Email },
+}
+
+// UserQuery 是 List 的條件，SortBy 必須是 SortColumns 之一
+type UserQuery struct {
+	NamePrefix  string
+	EmailPrefix string
+	SortBy      string
+	Desc        bool
+	After       *Position // 只回傳排在它之後的資料，nil 代表從頭開始
+	Limit       int
+}
+
+// Position 是某一筆資料在排序中的位置 (keyset pagination)
+type Position struct {
+	Value string // SortBy 欄位的值，依 id 排序時為空
+	ID    uint
+}
+
+// matches 與 GORM 實作的 WHERE 條件相同，給記憶體實作使用
+func (q UserQuery) matches(user *models.User) bool {
+	if !strings.HasPrefix(user.Name, q.NamePrefix) || !strings.HasPrefix(user.Email, q.EmailPrefix) {
+		return false
+	}
+	return q.After == nil || q.compare(user, q.After) > 0
+}
+
+// compare 回傳 user 在排序中位於 pos 之前 (<0) 或之後 (>0)
+func (q UserQuery) compare(user *models.User, pos *Position) int {
+	c := 0
+	if value := SortColumns[q.SortBy]; value != nil {
+		c = strings.Compare(value(user), pos.Value)
+	}
+	if c == 0 {
+		switch {
+		case user.ID < pos.ID:
+			c = -1
+		case user.ID > pos.ID:
+			c = 1
+		}
+	}
+	if q.Desc {
+		return -c
+	}
+	return c
+}
+
+// userSnapshot 是寫進稽核紀錄的使用者內容，刻意不包含密碼
+type userSnapshot struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// newAuditLog 建立一筆稽核紀錄，before/after 為 nil 代表新增或刪除
+func newAuditLog(ctx context.Context, action string, userID uint, before, after *models.User) (*models.UserAuditLog, error) {
+	log := &models.UserAuditLog{
+		UserID:    userID,
+		Action:    action,
+		RequestID: requestid.FromContext(ctx),
+	}
+	// 用 client 憑證呼叫的沒有對應的使用者，和未登入一樣不記錄 actor
+	if claims, ok := auth.FromContext(ctx); ok && claims.UserID != 0 {
+		log.ActorID = &claims.UserID
+	}
+
+	var err error
+	if log.Before, err = snapshot(before); err != nil {
+		return nil, err
+	}
+	if log.After, err = snapshot(after); err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+func snapshot(user *models.User) (*string, error) {
+	if user == nil {
+		return nil, nil
+	}
+	s := userSnapshot{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+	if user.DeletedAt.Valid {
+		s.DeletedAt = &user.DeletedAt.Time
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	str := string(data)
+	return &str, nil
+}
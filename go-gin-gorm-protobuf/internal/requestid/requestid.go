@@ -0,0 +1,63 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-gin-gorm-protobuf/internal/grpcutil"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Header 是 HTTP 與 gRPC metadata 共用的 request ID 欄位，沒帶的話由 server 產生
+const Header = "X-Request-ID"
+
+type key struct{}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if id == "" {
+			id = uuid.NewString()
+		}
+		c.Header(Header, id)
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id := incoming(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(Header, id))
+		return handler(NewContext(ctx, id), req)
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := incoming(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(Header, id))
+		return handler(srv, grpcutil.WithContext(ss, NewContext(ss.Context(), id)))
+	}
+}
+
+// incoming 取出 client 帶的 request ID，沒有的話產生一個
+func incoming(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(Header); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return uuid.NewString()
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Route 是從 google.api.http 註解解析出來的一條 HTTP 路由
+type Route struct {
+	Method     string
+	Path       string // gin 格式，例如 /users/:id
+	Pattern    string // 註解上的原始格式，例如 /users/{id}
+	FullMethod string // gRPC 格式，例如 /proto.UserService/GetUser
+	Descriptor protoreflect.MethodDescriptor
+	Rule       *annotations.HttpRule
+}
+
+// MIMEMergePatch 是 PATCH /users/{id} 使用的 JSON Merge Patch (RFC 7396)
+const MIMEMergePatch = "application/merge-patch+json"
+
+var pathParam = regexp.MustCompile(`\{([a-z_][a-z0-9_]*)\}`)
+
+// RegisterGateway 把每個 RPC 的 HTTP 註解註冊成 gin 路由並套上該 RPC 的授權規則，
+// JSON <-> protobuf 的轉換交給 grpc-gateway。grpc-gateway 直接呼叫 service 實作，不會經過 grpc.Server 的
+// interceptor，所以每條路由另外套上 gatewayUnary，記錄、統計與預設期限和 unary RPC 一致
+func RegisterGateway(router gin.IRoutes, services Services, tokens *auth.TokenManager, opts Options) error {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	jsonPb := &runtime.JSONPb{
+		MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+	}
+	mux := runtime.NewServeMux(
+		runtime.WithErrorHandler(gatewayError),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, jsonPb),
+		runtime.WithMarshalerOption(MIMEMergePatch, &mergePatch{JSONPb: jsonPb}),
+	)
+	if err := pb.RegisterUserServiceHandlerServer(context.Background(), mux, services.Users); err != nil {
+		return err
+	}
+	if err := pb.RegisterAuthServiceHandlerServer(context.Background(), mux, services.Auth); err != nil {
+		return err
+	}
+
+	routes, err := gatewayRoutes()
+	if err != nil {
+		return err
+	}
+
+	handler := gin.WrapH(mux)
+	for _, route := range routes {
+		router.Handle(route.Method, route.Path,
+			gatewayUnary(opts.Logger, opts.RequestTimeout, route.FullMethod),
+			auth.GinMiddleware(tokens, route.FullMethod),
+			handler)
+	}
+	return nil
+}
+
+// gatewayRoutes 是 RegisterGateway 註冊的所有路由，OpenAPI 文件也由同一份產生
+func gatewayRoutes() ([]Route, error) {
+	var routes []Route
+	for _, sd := range []protoreflect.ServiceDescriptor{
+		pb.File_user_proto.Services().ByName("UserService"),
+		pb.File_auth_proto.Services().ByName("AuthService"),
+	} {
+		r, err := Routes(sd)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, r...)
+	}
+	return routes, nil
+}
+
+// Routes 列出 service 中所有帶 google.api.http 註解的路由
+func Routes(sd protoreflect.ServiceDescriptor) ([]Route, error) {
+	var routes []Route
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+
+		rules := append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...)
+		for _, r := range rules {
+			method, path, err := httpPattern(r)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+			}
+			routes = append(routes, Route{
+				Method:     method,
+				Path:       pathParam.ReplaceAllString(path, ":$1"),
+				Pattern:    path,
+				FullMethod: fmt.Sprintf("/%s/%s", sd.FullName(), md.Name()),
+				Descriptor: md,
+				Rule:       r,
+			})
+		}
+	}
+	return routes, nil
+}
+
+func httpPattern(rule *annotations.HttpRule) (string, string, error) {
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get, nil
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post, nil
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put, nil
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch, nil
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete, nil
+	case *annotations.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath(), nil
+	}
+	return "", "", fmt.Errorf("unsupported http rule %v", rule)
+}
+
+// mergePatch 解析 JSON Merge Patch：沒帶的欄位不修改，有帶的欄位覆蓋。
+// 使用者的欄位都不能刪除，所以 RFC 7396 中代表刪除的 null 直接回 400
+type mergePatch struct {
+	*runtime.JSONPb
+}
+
+func (m *mergePatch) NewDecoder(r io.Reader) runtime.Decoder {
+	return runtime.DecoderFunc(func(v interface{}) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		for _, name := range slices.Sorted(maps.Keys(fields)) {
+			if bytes.Equal(bytes.TrimSpace(fields[name]), []byte("null")) {
+				return fmt.Errorf("field %q cannot be removed", name)
+			}
+		}
+		return m.Unmarshal(data, v)
+	})
+}
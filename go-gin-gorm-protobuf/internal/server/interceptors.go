@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/go-gin-gorm-protobuf/internal/grpcutil"
+	"github.com/go-gin-gorm-protobuf/internal/requestid"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	rpcHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of RPCs completed on the server, regardless of success or failure.",
+	}, []string{"grpc_type", "grpc_method", "grpc_code"})
+
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Histogram of response latency (seconds) of RPCs handled by the server.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_type", "grpc_method"})
+)
+
+// loggingUnary 每個 RPC 結束時記錄一行，放在 requestid 之後才拿得到 request ID
+func loggingUnary(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRPC(ctx, logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func loggingStream(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logRPC(ss.Context(), logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logRPC(ctx context.Context, logger *slog.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.NotFound, codes.AlreadyExists, codes.InvalidArgument, codes.Unauthenticated,
+		codes.PermissionDenied, codes.Canceled:
+	default:
+		level = slog.LevelError
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	logger.LogAttrs(ctx, level, "rpc finished", attrs...)
+}
+
+func metricsUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe("unary", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func metricsStream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observe(streamType(info), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observe(rpcType, method string, start time.Time, err error) {
+	rpcHandled.WithLabelValues(rpcType, method, status.Code(err).String()).Inc()
+	rpcDuration.WithLabelValues(rpcType, method).Observe(time.Since(start).Seconds())
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	}
+	return "server_stream"
+}
+
+// recoveryUnary 把 handler 的 panic 轉成 Internal，避免整個 server 掛掉
+func recoveryUnary(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func recoveryStream(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(ctx context.Context, logger *slog.Logger, method string, r interface{}) error {
+	logger.ErrorContext(ctx, "rpc panic",
+		slog.String("method", method),
+		slog.Any("panic", r),
+		slog.String("request_id", requestid.FromContext(ctx)),
+		slog.String("stack", string(debug.Stack())),
+	)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// deadlineUnary 在 client 沒有帶 deadline 時套用預設值，資料庫查詢會跟著 ctx 一起取消。
+// streaming RPC 可能會長時間連線，所以不套用
+func deadlineUnary(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// gatewayResult 讓 gatewayError 把 grpc-gateway 的錯誤交回 gatewayUnary
+type gatewayResult struct {
+	err error
+}
+
+type gatewayResultKey struct{}
+
+// gatewayUnary 對 REST 路由做 loggingUnary、metricsUnary、recoveryUnary 與 deadlineUnary 的工作，
+// 記錄與統計都以 fullMethod 為準，所以同一個 RPC 不論走 gRPC 或 REST 都算在一起
+func gatewayUnary(logger *slog.Logger, timeout time.Duration, fullMethod string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx := c.Request.Context()
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		result := &gatewayResult{}
+		c.Request = c.Request.WithContext(context.WithValue(ctx, gatewayResultKey{}, result))
+
+		defer func() {
+			if r := recover(); r != nil {
+				apperr.AbortWithProblem(c, recovered(ctx, logger, fullMethod, r))
+			}
+			// auth.GinMiddleware 拒絕時不會經過 grpc-gateway，錯誤記在 c.Errors
+			err := result.err
+			if err == nil && len(c.Errors) > 0 {
+				err = c.Errors.Last().Err
+			}
+			logRPC(ctx, logger, fullMethod, start, err)
+			observe("unary", fullMethod, start, err)
+		}()
+		c.Next()
+	}
+}
+
+// gatewayError 先把錯誤轉成 status 交給 gatewayUnary，再以 problem+json 回傳
+func gatewayError(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	err = apperr.ToStatus(r.Context(), err).Err()
+	if result, ok := r.Context().Value(gatewayResultKey{}).(*gatewayResult); ok {
+		result.err = err
+	}
+	apperr.GatewayErrorHandler(ctx, mux, m, w, r, err)
+}
+
+var errShuttingDown = apperr.New(apperr.Unavailable, "SHUTTING_DOWN", "server is shutting down, reconnect to another instance")
+
+// closingStream 在 server 開始關閉時取消 server-streaming RPC (例如 WatchUsers、health Watch)，
+// 否則它們會一直佔住連線直到 ShutdownTimeout。client-streaming 的 RPC 會自己結束，照常等待完成
+func closingStream(closing <-chan struct{}) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if info.IsClientStream {
+			return handler(srv, ss)
+		}
+
+		ctx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-closing:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		err := handler(srv, grpcutil.WithContext(ss, ctx))
+		select {
+		case <-closing:
+			return errShuttingDown
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package server_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	models.PasswordCost = bcrypt.MinCost
+	os.Exit(m.Run())
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	"github.com/go-gin-gorm-protobuf/internal/openapi"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const bearerAuth = "bearerAuth"
+
+// OpenAPI 依照 RegisterGateway 的路由產生 OpenAPI 文件。
+// 路徑、參數、body 與回應都來自 google.api.http 註解與 message 定義，授權規則來自 auth.Rules，
+// 所以改 proto 之後文件會跟著改
+func OpenAPI() (*openapi3.T, error) {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info:    &openapi3.Info{Title: "User service", Version: "1.0.0"},
+		Paths:   openapi3.NewPaths(),
+		Components: &openapi3.Components{
+			Schemas: openapi3.Schemas{},
+			SecuritySchemes: openapi3.SecuritySchemes{
+				bearerAuth: &openapi3.SecuritySchemeRef{
+					Value: openapi3.NewJWTSecurityScheme().WithDescription("Access token from POST /auth/login"),
+				},
+			},
+		},
+	}
+
+	problem, err := openapi.GoSchema(doc.Components.Schemas, apperr.Problem{})
+	if err != nil {
+		return nil, err
+	}
+	messages := openapi.Messages{Components: doc.Components.Schemas}
+
+	routes, err := gatewayRoutes()
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		doc.AddOperation(route.Pattern, route.Method, operation(messages, problem, route))
+	}
+
+	metrics := openapi3.NewOperation()
+	metrics.OperationID = "Metrics"
+	metrics.Summary = "Prometheus metrics"
+	metrics.Tags = []string{"Operations"}
+	metrics.Security = &openapi3.SecurityRequirements{}
+	metrics.AddResponse(http.StatusOK, openapi3.NewResponse().
+		WithDescription("Metrics in the Prometheus text format").
+		WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"text/plain"})))
+	doc.AddOperation("/metrics", http.MethodGet, metrics)
+	return doc, nil
+}
+
+// operation 依照 grpc-gateway 的對應方式：路徑上的欄位是 path 參數，
+// body 為 * 時其餘欄位放在 body，沒有 body 時其餘欄位放在 query
+func operation(messages openapi.Messages, problem *openapi3.SchemaRef, route Route) *openapi3.Operation {
+	md := route.Descriptor
+	input := md.Input()
+
+	op := openapi3.NewOperation()
+	op.OperationID = string(md.Name())
+	op.Tags = []string{string(md.Parent().Name())}
+
+	inPath := map[string]bool{}
+	for _, match := range pathParam.FindAllStringSubmatch(route.Pattern, -1) {
+		fd := input.Fields().ByName(protoreflect.Name(match[1]))
+		if fd == nil {
+			continue
+		}
+		inPath[match[1]] = true
+		op.AddParameter(openapi3.NewPathParameter(match[1]).WithSchema(messages.Field(fd, true).Value))
+	}
+
+	switch body := route.Rule.GetBody(); body {
+	case "":
+		fields := input.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if inPath[string(fd.Name())] || fd.Message() != nil {
+				continue
+			}
+			// 有指定 json_name 的欄位用 json_name，例如 page_size 寫成 limit
+			name := string(fd.Name())
+			if fd.HasJSONName() {
+				name = fd.JSONName()
+			}
+			op.AddParameter(openapi3.NewQueryParameter(name).WithSchema(messages.Field(fd, true).Value))
+		}
+	case "*":
+		contentTypes := []string{"application/json"}
+		if route.Method == http.MethodPatch {
+			contentTypes = []string{MIMEMergePatch, "application/json"}
+		}
+		op.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).
+			WithSchemaRef(messages.Object(input, inPath).NewRef(), contentTypes)}
+	default:
+		fd := input.Fields().ByName(protoreflect.Name(body))
+		op.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).
+			WithSchemaRef(messages.Field(fd, false), []string{"application/json"})}
+	}
+
+	response := messages.Ref(md.Output())
+	if name := route.Rule.GetResponseBody(); name != "" {
+		response = messages.Field(md.Output().Fields().ByName(protoreflect.Name(name)), false)
+	}
+	op.AddResponse(http.StatusOK, openapi3.NewResponse().WithDescription("OK").WithJSONSchemaRef(response))
+	op.Responses.Set("default", &openapi3.ResponseRef{Value: openapi3.NewResponse().
+		WithDescription("Error, see reason for the error code").
+		WithContent(openapi3.NewContentWithSchemaRef(problem, []string{apperr.MIMEProblem}))})
+
+	if auth.Rules[route.FullMethod] == auth.Public {
+		op.Security = &openapi3.SecurityRequirements{}
+	} else {
+		op.Security = openapi3.NewSecurityRequirements().With(openapi3.NewSecurityRequirement().Authenticate(bearerAuth))
+		op.Description = "Requires " + ruleName(auth.Rules[route.FullMethod]) + "."
+	}
+	return op
+}
+
+func ruleName(rule auth.Rule) string {
+	switch rule {
+	case auth.Authenticated:
+		return "any signed-in user"
+	case auth.OwnerOrAdmin:
+		return "the user themself or an admin"
+	}
+	return "an admin"
+}
@@ -0,0 +1,41 @@
+package server_test
+
+import (
+	"slices"
+	"testing"
+
+	"ginswagger/swaggertest"
+	"github.com/gin-gonic/gin"
+	"github.com/go-gin-gorm-protobuf/internal/server"
+)
+
+func TestOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv, err := server.New(server.Services{}, nil, server.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := swaggertest.Spec(t, srv)
+	swaggertest.Documented(t, srv.Router.Routes(), doc)
+
+	create := doc.Paths.Find("/users").Post
+	body := create.RequestBody.Value.Content.Get("application/json").Schema.Value
+	if !slices.Equal(body.Required, []string{"name", "email", "password"}) || body.Properties["email"].Value.Format != "email" {
+		t.Errorf("CreateUser body: required %v, email %+v", body.Required, body.Properties["email"].Value)
+	}
+	if create.Security == nil || len(*create.Security) != 0 {
+		t.Errorf("CreateUser should be public, security = %v", create.Security)
+	}
+
+	update := doc.Paths.Find("/users/{id}").Patch
+	if _, ok := update.RequestBody.Value.Content.Get(server.MIMEMergePatch).Schema.Value.Properties["id"]; ok {
+		t.Error("UpdateUser body should not repeat the path parameter id")
+	}
+	if update.Parameters.GetByInAndName("path", "id") == nil {
+		t.Error("UpdateUser is missing the id path parameter")
+	}
+	if doc.Paths.Find("/users").Get.Parameters.GetByInAndName("query", "limit") == nil {
+		t.Error("ListUsers is missing the limit query parameter")
+	}
+}
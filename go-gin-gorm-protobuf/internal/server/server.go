@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ginswagger"
+	"github.com/gin-gonic/gin"
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	"github.com/go-gin-gorm-protobuf/internal/requestid"
+	"github.com/go-gin-gorm-protobuf/internal/validate"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Services 是對外提供的 gRPC service 實作，HTTP 路由也是由它們轉譯
+type Services struct {
+	Users pb.UserServiceServer
+	Auth  pb.AuthServiceServer
+}
+
+type Options struct {
+	Logger *slog.Logger // nil 時使用 slog.Default()
+	// RequestTimeout 是 client 沒帶 deadline 時 unary RPC 的預設期限，0 代表不限制
+	RequestTimeout time.Duration
+	// ShutdownTimeout 是收到停止訊號後等待進行中請求的時間，超過就強制關閉連線
+	ShutdownTimeout time.Duration
+	// Reflection 讓 grpcurl 等工具可以列出 service
+	Reflection bool
+	// TLS 為 nil 時使用明文的 h2c，有設定時 gRPC 與 HTTP 都走 TLS，見 tlsutil.Reloader
+	TLS *tls.Config
+	// Ready 定期檢查相依服務 (例如資料庫)，失敗時 health check 回報 NOT_SERVING；nil 代表永遠 SERVING
+	Ready         func(ctx context.Context) error
+	ReadyInterval time.Duration // 預設 5 秒
+}
+
+// Server 在同一個 listener 上同時提供 gRPC 與 HTTP/JSON
+type Server struct {
+	GRPC   *grpc.Server
+	Router *gin.Engine
+	Health *health.Server
+
+	opts Options
+	// 開始關閉時關閉，用來結束 WatchUsers 這類不會自己結束的 stream
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+func New(services Services, tokens *auth.TokenManager, opts Options) (*Server, error) {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.ReadyInterval <= 0 {
+		opts.ReadyInterval = 5 * time.Second
+	}
+
+	closing := make(chan struct{})
+
+	// 順序：request ID → 記錄與統計 (看到最終的 status) → panic → deadline → 語系 → 錯誤轉換 → 授權
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			requestid.UnaryServerInterceptor(),
+			loggingUnary(opts.Logger),
+			metricsUnary(),
+			recoveryUnary(opts.Logger),
+			deadlineUnary(opts.RequestTimeout),
+			validate.UnaryServerInterceptor(),
+			apperr.UnaryServerInterceptor(),
+			auth.UnaryServerInterceptor(tokens),
+		),
+		grpc.ChainStreamInterceptor(
+			requestid.StreamServerInterceptor(),
+			loggingStream(opts.Logger),
+			metricsStream(),
+			recoveryStream(opts.Logger),
+			validate.StreamServerInterceptor(),
+			apperr.StreamServerInterceptor(),
+			closingStream(closing),
+			auth.StreamServerInterceptor(tokens),
+		),
+	)
+	pb.RegisterUserServiceServer(grpcServer, services.Users)
+	pb.RegisterAuthServiceServer(grpcServer, services.Auth)
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if opts.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	router := gin.Default()
+	router.Use(requestid.GinMiddleware(), validate.GinMiddleware())
+	router.NoRoute(func(c *gin.Context) {
+		apperr.AbortWithProblem(c, apperr.New(apperr.NotFound, "ROUTE_NOT_FOUND", "no route for "+c.Request.URL.Path))
+	})
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	if err := RegisterGateway(router, services, tokens, opts); err != nil {
+		return nil, err
+	}
+	doc, err := OpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	if err := ginswagger.Register(router, doc); err != nil {
+		return nil, err
+	}
+
+	return &Server{GRPC: grpcServer, Router: router, Health: healthServer, opts: opts, closing: closing}, nil
+}
+
+// ServeHTTP 依照 Content-Type 分流：gRPC 請求交給 grpc.Server，其餘交給 gin
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		s.GRPC.ServeHTTP(w, r)
+		return
+	}
+	s.Router.ServeHTTP(w, r)
+}
+
+// Run 監聽 addr 直到 ctx 結束，見 Serve
+func (s *Server) Run(ctx context.Context, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, lis)
+}
+
+// Serve 在 lis 上提供服務，ctx 結束 (例如收到 SIGTERM) 後先把 health 設為 NOT_SERVING，
+// 不再接受新連線並等待進行中的 RPC 與 HTTP 請求完成，最多等 ShutdownTimeout
+func (s *Server) Serve(ctx context.Context, lis net.Listener) error {
+	// gRPC 需要 HTTP/2，沒有 TLS 時要開啟 h2c (prior knowledge)
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if s.opts.TLS != nil {
+		protocols.SetHTTP2(true)
+		lis = tls.NewListener(lis, s.opts.TLS)
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+
+	srv := &http.Server{
+		Handler:   s,
+		Protocols: protocols,
+	}
+
+	readyCtx, stopReady := context.WithCancel(ctx)
+	defer stopReady()
+	go s.watchReady(readyCtx)
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(lis) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.opts.Logger.Info("shutting down, draining in-flight requests", slog.Duration("timeout", s.opts.ShutdownTimeout))
+	stopReady()
+	s.Health.Shutdown()
+	s.closeOnce.Do(func() { close(s.closing) })
+
+	// grpc.Server.GracefulStop 不支援 ServeHTTP，由 http.Server 負責等待連線上的 stream 結束
+	shutdownCtx := context.Background()
+	if s.opts.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, s.opts.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		s.opts.Logger.Warn("graceful shutdown timed out, closing connections", slog.Any("error", err))
+		srv.Close()
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// watchReady 依 Options.Ready 的結果更新整體與每個 service 的 health 狀態
+func (s *Server) watchReady(ctx context.Context) {
+	services := []string{"", pb.UserService_ServiceDesc.ServiceName, pb.AuthService_ServiceDesc.ServiceName}
+	last := healthpb.HealthCheckResponse_UNKNOWN
+
+	ticker := time.NewTicker(s.opts.ReadyInterval)
+	defer ticker.Stop()
+	for {
+		state := healthpb.HealthCheckResponse_SERVING
+		if s.opts.Ready != nil {
+			checkCtx, cancel := context.WithTimeout(ctx, s.opts.ReadyInterval)
+			err := s.opts.Ready(checkCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				state = healthpb.HealthCheckResponse_NOT_SERVING
+				s.opts.Logger.Warn("readiness check failed", slog.Any("error", err))
+			}
+		}
+		if state != last {
+			for _, name := range services {
+				s.Health.SetServingStatus(name, state)
+			}
+			last = state
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	"github.com/go-gin-gorm-protobuf/internal/server"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+// fakeUsers 只實作 CreateUser (不需要登入)，行為由測試決定
+type fakeUsers struct {
+	pb.UnimplementedUserServiceServer
+	create func(ctx context.Context) error
+}
+
+func (f *fakeUsers) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := f.create(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.CreateUserResponse{User: &pb.User{Name: req.Name}}, nil
+}
+
+// running 是 serve 啟動的 server，stop 模擬收到 SIGTERM，Serve 回傳後 done 會被關閉
+type running struct {
+	addr   string
+	conn   *grpc.ClientConn
+	tokens *auth.TokenManager
+	stop   context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// serve 在隨機 port 上啟動 server 並建立連線
+func serve(t *testing.T, users pb.UserServiceServer, opts server.Options) *running {
+	t.Helper()
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	tokens := auth.NewTokenManager([]byte("test-secret-test-secret-test-secret"), time.Minute, time.Hour)
+	srv, err := server.New(server.Services{Users: users, Auth: &pb.UnimplementedAuthServiceServer{}}, tokens, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &running{addr: lis.Addr().String(), tokens: tokens, stop: cancel, done: make(chan struct{})}
+	go func() {
+		r.err = srv.Serve(ctx, lis)
+		close(r.done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-r.done
+	})
+
+	r.conn, err = grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.conn.Close() })
+	return r
+}
+
+func TestGracefulShutdownDrainsInFlightRPCs(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	users := &fakeUsers{create: func(ctx context.Context) error {
+		close(entered)
+		<-release
+		return nil
+	}}
+	srv := serve(t, users, server.Options{ShutdownTimeout: 5 * time.Second})
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := pb.NewUserServiceClient(srv.conn).CreateUser(context.Background(), &pb.CreateUserRequest{Name: "slow"})
+		result <- err
+	}()
+	<-entered
+
+	srv.stop()
+	select {
+	case <-srv.done:
+		t.Fatalf("Serve returned before the in-flight RPC finished: %v", srv.err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-result; err != nil {
+		t.Fatalf("in-flight RPC failed: %v", err)
+	}
+	<-srv.done
+	if srv.err != nil {
+		t.Fatalf("Serve = %v, want nil", srv.err)
+	}
+}
+
+func TestHealthFollowsReadiness(t *testing.T) {
+	var dbDown atomic.Bool
+	srv := serve(t, &fakeUsers{}, server.Options{
+		Ready: func(ctx context.Context) error {
+			if dbDown.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+		ReadyInterval: 10 * time.Millisecond,
+	})
+	client := healthpb.NewHealthClient(srv.conn)
+
+	waitFor := func(want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: pb.UserService_ServiceDesc.ServiceName})
+			if err == nil && resp.Status == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("health = %v, %v, want %v", resp.GetStatus(), err, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor(healthpb.HealthCheckResponse_SERVING)
+	dbDown.Store(true)
+	waitFor(healthpb.HealthCheckResponse_NOT_SERVING)
+	dbDown.Store(false)
+	waitFor(healthpb.HealthCheckResponse_SERVING)
+}
+
+func TestInterceptors(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(ctx context.Context) error
+		want   codes.Code
+	}{
+		{"panic is recovered", func(ctx context.Context) error { panic("boom") }, codes.Internal},
+		{"default deadline", func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("no deadline")
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		}, codes.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serve(t, &fakeUsers{create: tt.create}, server.Options{RequestTimeout: 50 * time.Millisecond})
+			client := pb.NewUserServiceClient(srv.conn)
+
+			_, err := client.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "x"})
+			if code := status.Code(err); code != tt.want {
+				t.Fatalf("code = %v (%v), want %v", code, err, tt.want)
+			}
+			// server 還活著
+			_, err = healthpb.NewHealthClient(srv.conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+			if err != nil {
+				t.Fatalf("health check after %s: %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestReflection(t *testing.T) {
+	srv := serve(t, &fakeUsers{}, server.Options{Reflection: true})
+
+	stream, err := reflectionpb.NewServerReflectionClient(srv.conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		found[s.Name] = true
+	}
+	for _, name := range []string{"proto.UserService", "proto.AuthService", "grpc.health.v1.Health"} {
+		if !found[name] {
+			t.Errorf("reflection does not list %s: %v", name, found)
+		}
+	}
+}
+
+// handled 從 /metrics 讀出 CreateUser 以 code 結束的次數
+func handled(t *testing.T, handler http.Handler, code string) float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	series := fmt.Sprintf(`grpc_server_handled_total{grpc_code=%q,grpc_method="/proto.UserService/CreateUser",grpc_type="unary"} `, code)
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, series); ok {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+// REST 請求不經過 grpc.Server，但記錄、統計、panic 與預設期限要和 TestInterceptors 一致
+func TestGatewayInterceptors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		create func(ctx context.Context) error
+		status int
+		code   string
+	}{
+		{"ok", func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("no deadline")
+			}
+			return nil
+		}, http.StatusOK, "OK"},
+		{"panic is recovered", func(ctx context.Context) error { panic("boom") }, http.StatusInternalServerError, "Internal"},
+		{"default deadline", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, http.StatusGatewayTimeout, "DeadlineExceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := server.New(server.Services{Users: &fakeUsers{create: tt.create}}, nil, server.Options{
+				Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+				RequestTimeout: 50 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := handled(t, srv, tt.code)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"x"}`))
+			req.Header.Set("Content-Type", "application/json")
+			srv.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("POST /users: %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			if got := handled(t, srv, tt.code); got != before+1 {
+				t.Errorf("grpc_server_handled_total{grpc_code=%q} = %v, want %v", tt.code, got, before+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/go-gin-gorm-protobuf/internal/repository"
+	"github.com/go-gin-gorm-protobuf/internal/server"
+	"github.com/go-gin-gorm-protobuf/internal/services"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"github.com/go-gin-gorm-protobuf/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// serveUsers 以記憶體 repository 啟動完整的 UserService，並回傳管理員 (id 1) 的 ctx
+func serveUsers(t *testing.T, opts server.Options) (*running, *services.UserService, context.Context) {
+	t.Helper()
+	repo := repository.NewMemoryUserRepository()
+	admin := models.User{Name: "admin", Email: "admin@example.com", Role: models.RoleAdmin}
+	if err := repo.Create(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+
+	userService := &services.UserService{Users: repo}
+	srv := serve(t, &service.Server{Service: userService}, opts)
+	return srv, userService, asUser(t, srv, admin.ID, models.RoleAdmin)
+}
+
+func asUser(t *testing.T, srv *running, id uint, role string) context.Context {
+	t.Helper()
+	pair, err := srv.tokens.Issue(id, role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+pair.AccessToken)
+}
+
+func TestImportUsers(t *testing.T) {
+	srv, _, ctx := serveUsers(t, server.Options{})
+	client := pb.NewUserServiceClient(srv.conn)
+
+	stream, err := client.ImportUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 三批 (100、100、50)，其中有格式錯誤、與既有使用者重複、與同一次匯入的前一筆重複
+	const total = 250
+	for row := 1; row <= total; row++ {
+		req := &pb.CreateUserRequest{
+			Name:     fmt.Sprintf("user %d", row),
+			Email:    fmt.Sprintf("user%d@example.com", row),
+			Password: "password1",
+		}
+		switch row {
+		case 7:
+			req.Email = "not-an-email"
+		case 42:
+			req.Email = "admin@example.com"
+		case 150:
+			req.Email = "user3@example.com"
+		}
+		if err := stream.Send(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Created != total-3 || resp.Failed != 3 || len(resp.Results) != total {
+		t.Fatalf("created %d, failed %d, %d results", resp.Created, resp.Failed, len(resp.Results))
+	}
+	wantReasons := map[int32]string{7: "INVALID_ARGUMENT", 42: "EMAIL_TAKEN", 150: "EMAIL_TAKEN"}
+	for i, result := range resp.Results {
+		if result.Row != int32(i+1) {
+			t.Fatalf("results[%d].Row = %d, results must be ordered by row", i, result.Row)
+		}
+		if want := wantReasons[result.Row]; result.Reason != want {
+			t.Errorf("row %d: reason = %q (%s), want %q", result.Row, result.Reason, result.Error, want)
+		}
+		if (result.Error == "") != (result.Id != 0) {
+			t.Errorf("row %d: id %d with error %q", result.Row, result.Id, result.Error)
+		}
+	}
+
+	list, err := client.ListUsers(ctx, &pb.ListUsersRequest{PageSize: 100, EmailPrefix: "user249@"})
+	if err != nil || len(list.Users) != 1 {
+		t.Fatalf("imported user is not listed: %v, %v", list, err)
+	}
+}
+
+func TestStreamAuthorization(t *testing.T) {
+	srv, _, _ := serveUsers(t, server.Options{})
+	client := pb.NewUserServiceClient(srv.conn)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"anonymous", context.Background(), codes.Unauthenticated},
+		{"regular user", asUser(t, srv, 2, models.RoleUser), codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watch, err := client.WatchUsers(tt.ctx, &pb.WatchUsersRequest{})
+			if err == nil {
+				_, err = watch.Recv()
+			}
+			if code := status.Code(err); code != tt.want {
+				t.Errorf("WatchUsers: code = %v, want %v", code, tt.want)
+			}
+
+			imp, err := client.ImportUsers(tt.ctx)
+			if err == nil {
+				_, err = imp.CloseAndRecv()
+			}
+			if code := status.Code(err); code != tt.want {
+				t.Errorf("ImportUsers: code = %v, want %v", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchUsers(t *testing.T) {
+	srv, users, ctx := serveUsers(t, server.Options{})
+	client := pb.NewUserServiceClient(srv.conn)
+
+	all := watch(t, client, ctx, nil)
+	deletes := watch(t, client, ctx, []pb.UserEvent_Type{pb.UserEvent_DELETED})
+
+	bg := context.Background()
+	user, err := users.CreateUser(bg, "carol", "carol@example.com", "password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "caroline"
+	if _, err := users.UpdateUser(bg, uint(user.Id), services.UserUpdate{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.DeleteUser(bg, uint(user.Id)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []struct {
+		typ  pb.UserEvent_Type
+		name string
+	}{{pb.UserEvent_CREATED, "carol"}, {pb.UserEvent_UPDATED, "caroline"}, {pb.UserEvent_DELETED, "caroline"}} {
+		event := recv(t, all)
+		if event.Type != want.typ || event.User.Name != want.name || event.OccurredAt == nil {
+			t.Errorf("event = %v, want %v %s", event, want.typ, want.name)
+		}
+	}
+	if event := recv(t, deletes); event.Type != pb.UserEvent_DELETED {
+		t.Errorf("filtered watch received %v", event.Type)
+	}
+}
+
+func TestWatchUsersEndsOnShutdown(t *testing.T) {
+	srv, _, ctx := serveUsers(t, server.Options{ShutdownTimeout: 10 * time.Second})
+	stream := watch(t, pb.NewUserServiceClient(srv.conn), ctx, nil)
+
+	start := time.Now()
+	srv.stop()
+	_, err := stream.Recv()
+	if code := status.Code(err); code != codes.Unavailable {
+		t.Fatalf("Recv after shutdown: %v, want Unavailable", err)
+	}
+	<-srv.done
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("shutdown waited %v for the watch stream", elapsed)
+	}
+}
+
+// watch 訂閱並等到 server 確實開始處理，避免事件在訂閱前就發出
+func watch(t *testing.T, client pb.UserServiceClient, ctx context.Context, types []pb.UserEvent_Type) grpc.ServerStreamingClient[pb.UserEvent] {
+	t.Helper()
+	stream, err := client.WatchUsers(ctx, &pb.WatchUsersRequest{Types: types})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+func recv(t *testing.T, stream grpc.ServerStreamingClient[pb.UserEvent]) *pb.UserEvent {
+	t.Helper()
+	event, err := stream.Recv()
+	if err == io.EOF || err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	return event
+}
@@ -0,0 +1,140 @@
+package server_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gin-gorm-protobuf/internal/server"
+	"github.com/go-gin-gorm-protobuf/internal/tlsutil"
+	"github.com/go-gin-gorm-protobuf/internal/tlsutil/tlstest"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+func TestMutualTLS(t *testing.T) {
+	serverCA := tlstest.NewCA(t, "server")
+	clientCA := tlstest.NewCA(t, "clients")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	tlstest.WriteFiles(t, serverCA.Server(t), certFile, keyFile)
+
+	reloader, err := tlsutil.NewReloader(tlsutil.Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: clientCA.CertFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, _, adminCtx := serveUsers(t, server.Options{TLS: reloader.Config()})
+
+	clientConfig := func(certs ...tls.Certificate) *tls.Config {
+		return &tls.Config{RootCAs: serverCA.Pool(), Certificates: certs}
+	}
+	dial := func(config *tls.Config) pb.UserServiceClient {
+		conn, err := grpc.NewClient(srv.addr, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewUserServiceClient(conn)
+	}
+
+	anonymous := dial(clientConfig())
+	admin := dial(clientConfig(clientCA.Client(t, "ops", "admin")))
+	reporting := dial(clientConfig(clientCA.Client(t, "reporting")))
+	// 其他 CA 簽發的憑證 client 不會送出 (不在 server 接受的 CA 清單中)，等同沒有憑證
+	untrusted := dial(clientConfig(serverCA.Client(t, "intruder", "admin")))
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		client pb.UserServiceClient
+		ctx    context.Context
+		call   func(pb.UserServiceClient, context.Context) error
+		want   codes.Code
+	}{
+		{"no certificate, no token", anonymous, ctx, listUsers, codes.Unauthenticated},
+		{"no certificate, token", anonymous, adminCtx, listUsers, codes.OK},
+		{"admin certificate", admin, ctx, listUsers, codes.OK},
+		{"admin certificate, admin only", admin, ctx, restoreUser, codes.NotFound},
+		{"user certificate", reporting, ctx, listUsers, codes.OK},
+		{"user certificate, admin only", reporting, ctx, restoreUser, codes.PermissionDenied},
+		// 憑證沒有 UserID (0)，不能當成 id 0 的擁有者
+		{"user certificate, owner of id 0", reporting, ctx, deleteUserZero, codes.PermissionDenied},
+		// token 優先於憑證
+		{"user certificate, admin token", reporting, adminCtx, restoreUser, codes.NotFound},
+		{"untrusted certificate", untrusted, ctx, listUsers, codes.Unauthenticated},
+		{"plaintext", pb.NewUserServiceClient(srv.conn), adminCtx, listUsers, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call(tt.client, tt.ctx)); code != tt.want {
+				t.Errorf("code = %v, want %v", code, tt.want)
+			}
+		})
+	}
+
+	t.Run("http", func(t *testing.T) {
+		for _, tt := range []struct {
+			config *tls.Config
+			want   int
+		}{
+			{clientConfig(), http.StatusUnauthorized},
+			{clientConfig(clientCA.Client(t, "ops", "admin")), http.StatusOK},
+		} {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tt.config}}
+			resp, err := client.Get("https://" + srv.addr + "/users")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("GET /users = %d, want %d", resp.StatusCode, tt.want)
+			}
+		}
+	})
+}
+
+func TestRequireClientCertificate(t *testing.T) {
+	serverCA := tlstest.NewCA(t, "server")
+	clientCA := tlstest.NewCA(t, "clients")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	tlstest.WriteFiles(t, serverCA.Server(t), certFile, keyFile)
+
+	reloader, err := tlsutil.NewReloader(tlsutil.Options{
+		CertFile: certFile, KeyFile: keyFile, ClientCAFile: clientCA.CertFile, RequireClientCert: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, _, adminCtx := serveUsers(t, server.Options{TLS: reloader.Config()})
+
+	conn, err := grpc.NewClient(srv.addr, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: serverCA.Pool()})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// 沒有憑證連 token 都用不到，handshake 就失敗了
+	if err := listUsers(pb.NewUserServiceClient(conn), adminCtx); status.Code(err) != codes.Unavailable {
+		t.Errorf("without a client certificate: %v, want Unavailable", err)
+	}
+}
+
+func listUsers(client pb.UserServiceClient, ctx context.Context) error {
+	_, err := client.ListUsers(ctx, &pb.ListUsersRequest{})
+	return err
+}
+
+func deleteUserZero(client pb.UserServiceClient, ctx context.Context) error {
+	_, err := client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 0})
+	return err
+}
+
+func restoreUser(client pb.UserServiceClient, ctx context.Context) error {
+	_, err := client.RestoreUser(ctx, &pb.RestoreUserRequest{Id: 99})
+	return err
+}
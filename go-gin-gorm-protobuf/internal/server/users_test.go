@@ -0,0 +1,328 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/go-gin-gorm-protobuf/internal/repository"
+	"github.com/go-gin-gorm-protobuf/internal/server"
+	"github.com/go-gin-gorm-protobuf/internal/services"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"github.com/go-gin-gorm-protobuf/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testServer 使用記憶體 repository，預先建立 alice (1)、bob (2) 與管理員 (3)
+type testServer struct {
+	handler http.Handler
+	tokens  map[string]string // 使用者名稱 -> access token
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewMemoryUserRepository()
+	tokens := auth.NewTokenManager([]byte("test-secret-test-secret-test-secret"), time.Minute, time.Hour)
+	ts := &testServer{tokens: map[string]string{}}
+
+	for _, u := range []models.User{
+		{Name: "alice", Email: "alice@example.com", Role: models.RoleUser},
+		{Name: "bob", Email: "bob@example.com", Role: models.RoleUser},
+		{Name: "admin", Email: "admin@example.com", Role: models.RoleAdmin},
+	} {
+		if err := u.SetPassword(u.Name + "-password"); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Create(context.Background(), &u); err != nil {
+			t.Fatal(err)
+		}
+		pair, err := tokens.Issue(u.ID, u.Role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts.tokens[u.Name] = pair.AccessToken
+	}
+
+	userService := &services.UserService{Users: repo}
+	srv, err := server.New(server.Services{
+		Users: &service.Server{Service: userService},
+		Auth:  &service.AuthServer{Service: userService, Tokens: tokens},
+	}, tokens, server.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.handler = srv
+	return ts
+}
+
+func (ts *testServer) do(t *testing.T, method, path, as, contentType, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if as != "" {
+		req.Header.Set("Authorization", "Bearer "+ts.tokens[as])
+	}
+	rec := httptest.NewRecorder()
+	ts.handler.ServeHTTP(rec, req)
+
+	var got map[string]interface{}
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("response is not a JSON object: %v\n%s", err, rec.Body)
+		}
+	}
+	return rec.Code, got
+}
+
+func TestUserRoutes(t *testing.T) {
+	const jsonType = "application/json"
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		as          string
+		contentType string
+		body        string
+		wantStatus  int
+		want        map[string]interface{} // 回應中必須出現的欄位
+	}{
+		{"create", "POST", "/users", "", jsonType, `{"name":"carol","email":"carol@example.com","password":"secret123"}`,
+			http.StatusOK, map[string]interface{}{"id": "4", "name": "carol", "role": "user"}},
+		{"create duplicate email", "POST", "/users", "", jsonType, `{"name":"x","email":"bob@example.com","password":"secret123"}`,
+			http.StatusConflict, map[string]interface{}{"reason": "EMAIL_TAKEN"}},
+		{"create missing fields", "POST", "/users", "", jsonType, `{"name":"x"}`,
+			http.StatusBadRequest, map[string]interface{}{"reason": "INVALID_ARGUMENT"}},
+		{"create malformed body", "POST", "/users", "", jsonType, `{"name":`, http.StatusBadRequest, nil},
+
+		{"get without token", "GET", "/users/1", "", "", "", http.StatusUnauthorized, map[string]interface{}{"reason": "UNAUTHENTICATED"}},
+		{"get", "GET", "/users/1", "bob", "", "", http.StatusOK, map[string]interface{}{"email": "alice@example.com"}},
+		{"get missing", "GET", "/users/99", "bob", "", "", http.StatusNotFound,
+			map[string]interface{}{"reason": "USER_NOT_FOUND", "instance": "/users/99"}},
+		{"unknown route", "GET", "/nope", "", "", "", http.StatusNotFound, map[string]interface{}{"reason": "ROUTE_NOT_FOUND"}},
+		{"get invalid id", "GET", "/users/0", "bob", "", "", http.StatusBadRequest, nil},
+
+		{"list first page", "GET", "/users?limit=2", "bob", "", "", http.StatusOK, nil},
+		{"list by name prefix", "GET", "/users?name_prefix=ad&sort=-name", "bob", "", "", http.StatusOK, nil},
+		{"list invalid sort", "GET", "/users?sort=password", "bob", "", "", http.StatusBadRequest, nil},
+
+		{"patch own name", "PATCH", "/users/2", "bob", "application/merge-patch+json", `{"name":"robert"}`,
+			http.StatusOK, map[string]interface{}{"name": "robert", "email": "bob@example.com"}},
+		{"patch other user", "PATCH", "/users/1", "bob", "application/merge-patch+json", `{"name":"x"}`,
+			http.StatusForbidden, map[string]interface{}{"reason": "PERMISSION_DENIED"}},
+		{"patch null field", "PATCH", "/users/2", "bob", "application/merge-patch+json", `{"name":null}`, http.StatusBadRequest, nil},
+		{"patch taken email", "PATCH", "/users/2", "bob", "application/merge-patch+json", `{"email":"alice@example.com"}`,
+			http.StatusConflict, nil},
+		{"admin patches other user", "PATCH", "/users/1", "admin", "application/merge-patch+json", `{"name":"alicia"}`,
+			http.StatusOK, map[string]interface{}{"name": "alicia"}},
+
+		{"put", "PUT", "/users/2", "bob", jsonType, `{"name":"bobby","email":"bobby@example.com"}`,
+			http.StatusOK, map[string]interface{}{"name": "bobby", "email": "bobby@example.com"}},
+		{"put missing email", "PUT", "/users/2", "bob", jsonType, `{"name":"bobby"}`, http.StatusBadRequest, nil},
+
+		{"delete own", "DELETE", "/users/2", "bob", "", "", http.StatusOK, nil},
+		{"delete missing", "DELETE", "/users/99", "admin", "", "", http.StatusNotFound, nil},
+		{"restore as user", "POST", "/users/2/restore", "bob", "", "", http.StatusForbidden, nil},
+		{"restore not deleted", "POST", "/users/2/restore", "admin", "", "", http.StatusNotFound, nil},
+		{"history", "GET", "/users/1/history", "alice", "", "", http.StatusOK, nil},
+
+		{"login", "POST", "/auth/login", "", jsonType, `{"email":"alice@example.com","password":"alice-password"}`,
+			http.StatusOK, map[string]interface{}{"token_type": "Bearer"}},
+		{"login wrong password", "POST", "/auth/login", "", jsonType, `{"email":"alice@example.com","password":"nope"}`,
+			http.StatusUnauthorized, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t)
+
+			status, got := ts.do(t, tt.method, tt.path, tt.as, tt.contentType, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %v", status, tt.wantStatus, got)
+			}
+			// 錯誤一律是 RFC 7807 problem+json
+			if status >= 400 && (got["status"] != float64(status) || got["title"] != http.StatusText(status)) {
+				t.Errorf("body is not a problem for %d: %v", status, got)
+			}
+			for field, want := range tt.want {
+				if got[field] != want {
+					t.Errorf("%s = %v, want %v", field, got[field], want)
+				}
+			}
+		})
+	}
+}
+
+func TestListUsersPagination(t *testing.T) {
+	ts := newTestServer(t)
+
+	var names []string
+	path := "/users?limit=2&sort=-name"
+	for page := 0; path != ""; page++ {
+		if page > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		status, got := ts.do(t, "GET", path, "bob", "", "")
+		if status != http.StatusOK {
+			t.Fatalf("status = %d, body %v", status, got)
+		}
+		for _, u := range got["users"].([]interface{}) {
+			names = append(names, u.(map[string]interface{})["name"].(string))
+		}
+		path = ""
+		if next, _ := got["next_cursor"].(string); next != "" {
+			path = "/users?limit=2&sort=-name&cursor=" + next
+		}
+	}
+
+	if want := "bob,alice,admin"; strings.Join(names, ",") != want {
+		t.Errorf("names = %v, want %s", names, want)
+	}
+}
+
+func TestSoftDeleteLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+
+	steps := []struct {
+		method, path, as string
+		wantStatus       int
+	}{
+		{"DELETE", "/users/2", "admin", http.StatusOK},
+		{"GET", "/users/2", "admin", http.StatusNotFound},
+		{"POST", "/users", "", http.StatusOK}, // 刪除後 email 可以重新註冊
+		{"POST", "/users/2/restore", "admin", http.StatusConflict},
+		{"DELETE", "/users/4", "admin", http.StatusOK},
+		{"POST", "/users/2/restore", "admin", http.StatusOK},
+		{"GET", "/users/2", "admin", http.StatusOK},
+	}
+	for _, step := range steps {
+		body := ""
+		if step.method == "POST" && step.path == "/users" {
+			body = `{"name":"bob2","email":"bob@example.com","password":"secret123"}`
+		}
+		status, got := ts.do(t, step.method, step.path, step.as, "application/json", body)
+		if status != step.wantStatus {
+			t.Fatalf("%s %s: status = %d, want %d, body %v", step.method, step.path, status, step.wantStatus, got)
+		}
+	}
+
+	_, got := ts.do(t, "GET", "/users/2/history", "admin", "", "")
+	var actions []string
+	for _, e := range got["entries"].([]interface{}) {
+		actions = append(actions, e.(map[string]interface{})["action"].(string))
+	}
+	if want := "create,delete,restore"; strings.Join(actions, ",") != want {
+		t.Errorf("actions = %v, want %s", actions, want)
+	}
+}
+
+func TestGRPCErrorDetails(t *testing.T) {
+	ts := newTestServer(t)
+	srv := ts.handler.(*server.Server)
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.GRPC.Serve(lis)
+	t.Cleanup(srv.GRPC.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewUserServiceClient(conn)
+
+	_, err = client.CreateUser(context.Background(), &pb.CreateUserRequest{Name: "x"})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %v, want InvalidArgument", st.Code())
+	}
+
+	var reason string
+	var fields []string
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			reason = d.Reason
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	if reason != "INVALID_ARGUMENT" || strings.Join(fields, ",") != "email,password" {
+		t.Errorf("reason = %q, fields = %v", reason, fields)
+	}
+
+	_, err = client.GetUser(context.Background(), &pb.GetUserRequest{Id: 1})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Errorf("GetUser without token: code = %v, want Unauthenticated", code)
+	}
+}
+
+func TestValidationLocale(t *testing.T) {
+	ts := newTestServer(t)
+	body := `{"name":"  ","email":"not-an-email","password":"short"}`
+
+	tests := []struct {
+		acceptLanguage string
+		want           []string
+	}{
+		{"", []string{
+			"name: is required",
+			"email: must be a valid email address",
+			"password: must be at least 8 characters",
+			"password: must contain a digit",
+		}},
+		{"zh-TW,zh;q=0.9,en;q=0.8", []string{
+			"name: 為必填欄位",
+			"email: 必須是有效的 email 地址",
+			"password: 至少需要 8 個字元",
+			"password: 必須包含數字",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			rec := httptest.NewRecorder()
+			ts.handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+			var problem struct {
+				Errors []struct{ Field, Message string }
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range problem.Errors {
+				got = append(got, e.Field+": "+e.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
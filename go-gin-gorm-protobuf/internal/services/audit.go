@@ -0,0 +1,43 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toAuditPB(log *models.UserAuditLog) (*pb.UserAuditEntry, error) {
+	entry := &pb.UserAuditEntry{
+		Id:        int64(log.ID),
+		UserId:    int64(log.UserID),
+		Action:    log.Action,
+		RequestId: log.RequestID,
+		CreatedAt: timestamppb.New(log.CreatedAt),
+	}
+	if log.ActorID != nil {
+		entry.ActorId = int64(*log.ActorID)
+	}
+
+	var err error
+	if entry.Before, err = toStruct(log.Before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = toStruct(log.After); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func toStruct(data *string) (*structpb.Struct, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(*data), &fields); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(fields)
+}
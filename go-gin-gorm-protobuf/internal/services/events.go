@@ -0,0 +1,86 @@
+package services
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WatchBuffer 是每個訂閱者最多能累積的事件數，滿了代表跟不上，會被中斷
+const WatchBuffer = 256
+
+var ErrWatcherTooSlow = apperr.New(apperr.Unavailable, "WATCHER_TOO_SLOW", "subscriber fell behind, resubscribe and reload")
+
+// broker 把使用者的變更推給 WatchUsers 的訂閱者，zero value 即可使用。
+// 事件只在 transaction 成功之後發出，而且只在這個程序內傳遞
+type broker struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	events chan *pb.UserEvent
+	types  []pb.UserEvent_Type
+	// 因為跟不上而被移除時關閉
+	dropped chan struct{}
+}
+
+// Subscription 是 Watch 回傳的訂閱，用完要呼叫 Close
+type Subscription struct {
+	b   *broker
+	sub *subscriber
+}
+
+// Events 在訂閱因為跟不上而中斷時不會再有新事件，此時 Dropped 會被關閉
+func (s *Subscription) Events() <-chan *pb.UserEvent {
+	return s.sub.events
+}
+
+func (s *Subscription) Dropped() <-chan struct{} {
+	return s.sub.dropped
+}
+
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	delete(s.b.subscribers, s.sub)
+}
+
+// Watch 訂閱使用者的變更事件，types 為空代表全部
+func (s *UserService) Watch(types []pb.UserEvent_Type) *Subscription {
+	sub := &subscriber{
+		events:  make(chan *pb.UserEvent, WatchBuffer),
+		types:   types,
+		dropped: make(chan struct{}),
+	}
+
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	if s.events.subscribers == nil {
+		s.events.subscribers = make(map[*subscriber]struct{})
+	}
+	s.events.subscribers[sub] = struct{}{}
+	return &Subscription{b: &s.events, sub: sub}
+}
+
+func (b *broker) publish(eventType pb.UserEvent_Type, user *pb.User) {
+	event := &pb.UserEvent{Type: eventType, User: user, OccurredAt: timestamppb.Now()}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		if len(sub.types) > 0 && !slices.Contains(sub.types, eventType) {
+			continue
+		}
+		// 不能讓慢的訂閱者拖住寫入，buffer 滿了就中斷它，讓 client 重新訂閱並重新載入
+		select {
+		case sub.events <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.dropped)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	pb "github.com/go-gin-gorm-protobuf/proto"
+)
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	var s UserService
+	slow := s.Watch(nil)
+	defer slow.Close()
+	deletes := s.Watch([]pb.UserEvent_Type{pb.UserEvent_DELETED})
+	defer deletes.Close()
+
+	for i := 0; i <= WatchBuffer; i++ {
+		s.events.publish(pb.UserEvent_CREATED, &pb.User{Id: int64(i)})
+	}
+
+	select {
+	case <-slow.Dropped():
+	default:
+		t.Fatal("subscriber with a full buffer was not dropped")
+	}
+	if got := len(slow.Events()); got != WatchBuffer {
+		t.Errorf("buffered %d events, want %d", got, WatchBuffer)
+	}
+
+	// 被過濾掉的事件不佔 buffer
+	s.events.publish(pb.UserEvent_DELETED, &pb.User{Id: 1})
+	select {
+	case <-deletes.Dropped():
+		t.Fatal("filtered subscriber was dropped")
+	case event := <-deletes.Events():
+		if event.Type != pb.UserEvent_DELETED {
+			t.Errorf("received %v", event.Type)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+)
+
+// ImportBatchSize 是 ImportUsers 每個 transaction 的筆數
+const ImportBatchSize = 100
+
+type NewUser struct {
+	Name     string
+	Email    string
+	Password string
+}
+
+// ImportResult 是單筆匯入的結果，Err 為 nil 代表成功
+type ImportResult struct {
+	User *pb.User
+	Err  error
+}
+
+// ImportUsers 在同一個 transaction 中建立 rows (呼叫端負責切成 ImportBatchSize 一批)，
+// 只有整批失敗 (例如資料庫斷線) 才回傳 error
+func (s *UserService) ImportUsers(ctx context.Context, rows []NewUser) ([]ImportResult, error) {
+	results := make([]ImportResult, len(rows))
+	users := make([]*models.User, len(rows))
+
+	// bcrypt 很慢，平行計算
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i, row := range rows {
+		users[i] = &models.User{Name: row.Name, Email: row.Email, Role: models.RoleUser}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			results[i].Err = users[i].SetPassword(row.Password)
+			<-sem
+		}()
+	}
+	wg.Wait()
+
+	var pending []*models.User
+	var index []int
+	for i, user := range users {
+		if results[i].Err == nil {
+			pending = append(pending, user)
+			index = append(index, i)
+		}
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	errs, err := s.Users.CreateMany(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range index {
+		if results[i].Err = errs[j]; results[i].Err == nil {
+			results[i].User = toPB(pending[j])
+			s.events.publish(pb.UserEvent_CREATED, results[i].User)
+		}
+	}
+	return results, nil
+}
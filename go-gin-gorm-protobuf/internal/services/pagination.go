@@ -0,0 +1,93 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/go-gin-gorm-protobuf/internal/repository"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrInvalidListOptions = apperr.New(apperr.Validation, "INVALID_LIST_OPTIONS", "invalid list options")
+
+type ListOptions struct {
+	Limit       int
+	Cursor      string
+	NamePrefix  string
+	EmailPrefix string
+	Sort        string // 欄位名稱，前面加 - 代表遞減
+}
+
+type UserPage struct {
+	Users      []*pb.User
+	NextCursor string
+}
+
+// cursor 記錄上一頁最後一筆的排序值，下一頁從它之後開始 (keyset pagination)
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint   `json:"id"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解析 cursor，並確認它是用同一個排序產生的
+func decodeCursor(token string, sort string) (*cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidListOptions, c.Sort)
+	}
+	return &c, nil
+}
+
+func pageSize(limit int) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, fmt.Errorf("%w: limit must not be negative", ErrInvalidListOptions)
+	case limit == 0:
+		return DefaultPageSize, nil
+	case limit > MaxPageSize:
+		return MaxPageSize, nil
+	}
+	return limit, nil
+}
+
+// parseSort 回傳排序欄位與是否遞減
+func parseSort(sort string) (string, bool, error) {
+	if sort == "" {
+		return "id", false, nil
+	}
+	column, desc := strings.CutPrefix(sort, "-")
+	if _, ok := repository.SortColumns[column]; !ok {
+		return "", false, fmt.Errorf("%w: cannot sort by %q", ErrInvalidListOptions, column)
+	}
+	return column, desc, nil
+}
+
+func nextCursor(sort string, column string, last *models.User) string {
+	c := cursor{Sort: sort, ID: last.ID}
+	if value := repository.SortColumns[column]; value != nil {
+		c.Value = value(last)
+	}
+	return c.encode()
+}
@@ -1,78 +1,191 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"fmt"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
 	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/go-gin-gorm-protobuf/internal/repository"
 	pb "github.com/go-gin-gorm-protobuf/proto"
-	"gorm.io/gorm"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	ErrUserNotFound = repository.ErrUserNotFound
+	ErrEmailTaken   = repository.ErrEmailTaken
+
+	ErrInvalidCredentials = apperr.New(apperr.Unauthorized, "INVALID_CREDENTIALS", "invalid email or password")
 )
 
 type UserService struct {
-	DB *gorm.DB
+	Users repository.UserRepository
+
+	events broker
+}
+
+// UserUpdate 為 nil 的欄位代表不修改
+type UserUpdate struct {
+	Name     *string
+	Email    *string
+	Password *string
 }
 
-func (s *UserService) CreateUser(name string, email string, password string) (*pb.User, error) {
-	user := models.User{Name: name, Email: email}
+func (s *UserService) CreateUser(ctx context.Context, name string, email string, password string) (*pb.User, error) {
+	user := models.User{Name: name, Email: email, Role: models.RoleUser}
 	err := user.SetPassword(password)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.DB.Create(&user).Error
-	if err != nil {
+	if err := s.Users.Create(ctx, &user); err != nil {
 		return nil, err
 	}
+	created := toPB(&user)
+	s.events.publish(pb.UserEvent_CREATED, created)
+	return created, nil
+}
 
-	return &pb.User{
-		Id:    int64(user.ID),
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+func (s *UserService) GetUser(ctx context.Context, id uint) (*pb.User, error) {
+	user, err := s.Users.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toPB(user), nil
 }
 
-func (s *UserService) GetUsers() ([]*pb.User, error) {
-	var users []*pb.User
-	err := s.DB.Find(&users).Error
+// Authenticate 以 email 與密碼驗證使用者，不區分是 email 不存在還是密碼錯誤
+func (s *UserService) Authenticate(ctx context.Context, email string, password string) (*pb.User, error) {
+	user, err := s.Users.FindByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	var pbUsers []*pb.User
-	for _, user := range users {
-		pbUsers = append(pbUsers, &pb.User{
-			Id:    user.Id,
-			Name:  user.Name,
-			Email: user.Email,
-		})
+	if !user.CheckPassword(password) {
+		return nil, ErrInvalidCredentials
 	}
-	return pbUsers, nil
+	return toPB(user), nil
 }
 
-func (s *UserService) DeleteUser(id uint) (string, error) {
-	var user models.User
+// ListUsers 依照 opts 篩選、排序並以 cursor 分頁
+func (s *UserService) ListUsers(ctx context.Context, opts ListOptions) (*UserPage, error) {
+	limit, err := pageSize(opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	column, desc, err := parseSort(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
 
-	result := s.DB.First(&user, id)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return "", fmt.Errorf("user with id %d not found", id)
+	query := repository.UserQuery{
+		NamePrefix:  opts.NamePrefix,
+		EmailPrefix: opts.EmailPrefix,
+		SortBy:      column,
+		Desc:        desc,
+		// 多抓一筆判斷是否還有下一頁
+		Limit: limit + 1,
+	}
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor, opts.Sort)
+		if err != nil {
+			return nil, err
 		}
-		return "", result.Error
+		query.After = &repository.Position{Value: c.Value, ID: c.ID}
 	}
 
-	r := s.DB.Delete(&user)
-	if r.Error != nil {
-		return "", r.Error
+	users, err := s.Users.List(ctx, query)
+	if err != nil {
+		return nil, err
 	}
 
-	// 檢查是否真的刪除了一筆資料
-	if r.RowsAffected == 0 {
-		return "", fmt.Errorf("no record deleted")
+	page := &UserPage{}
+	if len(users) > limit {
+		users = users[:limit]
+		page.NextCursor = nextCursor(opts.Sort, column, &users[limit-1])
 	}
 
-	return "success", nil
+	page.Users = make([]*pb.User, 0, len(users))
+	for i := range users {
+		page.Users = append(page.Users, toPB(&users[i]))
+	}
+	return page, nil
 }
 
-//func (s *UserService) UpdateUser(id uint, name string, email string) (*proto.User, error) {
-//	var user models.User
-//}
+func (s *UserService) UpdateUser(ctx context.Context, id uint, update UserUpdate) (*pb.User, error) {
+	user, err := s.Users.Update(ctx, id, func(user *models.User) error {
+		if update.Name != nil {
+			user.Name = *update.Name
+		}
+		if update.Email != nil {
+			user.Email = *update.Email
+		}
+		if update.Password != nil {
+			return user.SetPassword(*update.Password)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	updated := toPB(user)
+	s.events.publish(pb.UserEvent_UPDATED, updated)
+	return updated, nil
+}
+
+// DeleteUser 為軟刪除，只會設定 deleted_at
+func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
+	// 事件要帶刪除前的資料，刪除後就讀不到了
+	user, err := s.Users.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.Users.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.events.publish(pb.UserEvent_DELETED, toPB(user))
+	return nil
+}
+
+// RestoreUser 復原被軟刪除的使用者，若 email 已被其他人使用則回傳 ErrEmailTaken
+func (s *UserService) RestoreUser(ctx context.Context, id uint) (*pb.User, error) {
+	user, err := s.Users.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	restored := toPB(user)
+	s.events.publish(pb.UserEvent_RESTORED, restored)
+	return restored, nil
+}
+
+// ListUserHistory 依時間順序回傳使用者的稽核紀錄，包含已刪除的使用者
+func (s *UserService) ListUserHistory(ctx context.Context, id uint) ([]*pb.UserAuditEntry, error) {
+	logs, err := s.Users.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*pb.UserAuditEntry, 0, len(logs))
+	for i := range logs {
+		entry, err := toAuditPB(&logs[i])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func toPB(user *models.User) *pb.User {
+	return &pb.User{
+		Id:        int64(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
@@ -0,0 +1,153 @@
+// Package tlsutil 提供會從磁碟重新載入憑證的 server TLS 設定
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile 有設定時會驗證 client 憑證，憑證由這個 CA 簽發才算有效
+	ClientCAFile string
+	// RequireClientCert 為 false 時 client 可以不帶憑證 (改用 token)，但帶了就必須有效
+	RequireClientCert bool
+	Logger            *slog.Logger // nil 時使用 slog.Default()
+}
+
+// Reloader 持有目前的憑證，Reload 在檔案有變動時重新讀取，新的連線就會用新的憑證，
+// 已經建立的連線不受影響。讀取失敗時保留原本的憑證
+type Reloader struct {
+	opts Options
+
+	mu      sync.RWMutex
+	config  *tls.Config
+	version string // 檔案的大小與修改時間，沒變就不重新讀取
+}
+
+// NewReloader 讀取一次憑證，檔案有問題時直接回傳錯誤
+func NewReloader(opts Options) (*Reloader, error) {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	r := &Reloader{opts: opts}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config 回傳給 http.Server 使用的設定，每次 handshake 都會取用最新的憑證
+func (r *Reloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.config, nil
+		},
+	}
+}
+
+// Reload 在檔案有變動時重新讀取，回傳是否換了新的憑證
+func (r *Reloader) Reload() (bool, error) {
+	version, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	unchanged := version == r.version
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	config, err := r.load()
+	if err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	r.config, r.version = config, version
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch 每隔 interval 檢查一次檔案，直到 ctx 結束。
+// k8s 更新 secret 時會換掉整個目錄的 symlink，所以用輪詢而不是監聽檔案事件
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := r.Reload()
+		switch {
+		case err != nil:
+			r.opts.Logger.Error("failed to reload TLS certificate, keeping the current one", slog.Any("error", err))
+		case reloaded:
+			r.opts.Logger.Info("reloaded TLS certificate", slog.String("cert_file", r.opts.CertFile))
+		}
+	}
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		files = append(files, r.opts.ClientCAFile)
+	}
+	return files
+}
+
+func (r *Reloader) stat() (string, error) {
+	var version strings.Builder
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&version, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return version.String(), nil
+}
+
+func (r *Reloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + r.opts.ClientCAFile)
+		}
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.opts.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,138 @@
+package tlsutil_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-gin-gorm-protobuf/internal/tlsutil"
+	"github.com/go-gin-gorm-protobuf/internal/tlsutil/tlstest"
+)
+
+func TestReload(t *testing.T) {
+	ca := tlstest.NewCA(t, "test")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	first := ca.Server(t)
+	tlstest.WriteFiles(t, first, certFile, keyFile)
+
+	r, err := tlsutil.NewReloader(tlsutil.Options{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", r.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+
+	if got := serverCert(t, lis.Addr(), ca.Pool()); !got.Equal(first.Leaf) {
+		t.Fatal("handshake did not use the initial certificate")
+	}
+	if reloaded, err := r.Reload(); reloaded || err != nil {
+		t.Fatalf("Reload without changes = %v, %v", reloaded, err)
+	}
+
+	// 讀不到的檔案不會換掉目前的憑證
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reload(); err == nil {
+		t.Fatal("Reload accepted a broken key")
+	}
+	if got := serverCert(t, lis.Addr(), ca.Pool()); !got.Equal(first.Leaf) {
+		t.Fatal("broken files replaced the certificate")
+	}
+
+	second := ca.Server(t)
+	tlstest.WriteFiles(t, second, certFile, keyFile)
+	if reloaded, err := r.Reload(); !reloaded || err != nil {
+		t.Fatalf("Reload after rotation = %v, %v", reloaded, err)
+	}
+	if got := serverCert(t, lis.Addr(), ca.Pool()); !got.Equal(second.Leaf) {
+		t.Fatal("handshake did not use the rotated certificate")
+	}
+}
+
+func TestClientCertificates(t *testing.T) {
+	ca := tlstest.NewCA(t, "server")
+	clients := tlstest.NewCA(t, "clients")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	tlstest.WriteFiles(t, ca.Server(t), certFile, keyFile)
+
+	trusted := clients.Client(t, "billing")
+	untrusted := tlstest.NewCA(t, "other").Client(t, "billing")
+
+	tests := []struct {
+		name    string
+		require bool
+		certs   []tls.Certificate
+		wantErr bool
+	}{
+		{"optional without certificate", false, nil, false},
+		{"optional with trusted certificate", false, []tls.Certificate{trusted}, false},
+		{"optional with untrusted certificate", false, []tls.Certificate{untrusted}, true},
+		{"required without certificate", true, nil, true},
+		{"required with trusted certificate", true, []tls.Certificate{trusted}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tlsutil.NewReloader(tlsutil.Options{
+				CertFile: certFile, KeyFile: keyFile, ClientCAFile: clients.CertFile, RequireClientCert: tt.require,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client, server := net.Pipe()
+			defer client.Close()
+			go func() {
+				// 不管 server 接受哪些 CA 都送出憑證，Certificates 只會送出符合的
+				tls.Client(client, &tls.Config{
+					ServerName: "localhost",
+					RootCAs:    ca.Pool(),
+					GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+						if len(tt.certs) == 0 {
+							return &tls.Certificate{}, nil
+						}
+						return &tt.certs[0], nil
+					},
+				}).Handshake()
+				client.Close()
+			}()
+
+			conn := tls.Server(server, r.Config())
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			err = conn.Handshake()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("handshake error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func serverCert(t *testing.T, addr net.Addr, roots *x509.CertPool) *x509.Certificate {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr.String(), &tls.Config{ServerName: "localhost", RootCAs: roots})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0]
+}
@@ -0,0 +1,127 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-gin-gorm-protobuf/internal/grpcutil"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Locale 是驗證錯誤訊息的語系，由 Accept-Language (HTTP header 或 gRPC metadata) 決定
+type Locale int
+
+const (
+	English Locale = iota
+	TraditionalChinese
+)
+
+type rule int
+
+const (
+	ruleInvalid rule = iota
+	ruleRequired
+	ruleMinLen
+	ruleMaxLen
+	ruleEmail
+	rulePositive
+	ruleMaxBytes
+	ruleLetter
+	ruleDigit
+	ruleSymbol
+)
+
+var messages = map[Locale]map[rule]string{
+	English: {
+		ruleInvalid:  "invalid argument",
+		ruleRequired: "is required",
+		ruleMinLen:   "must be at least %d characters",
+		ruleMaxLen:   "must be at most %d characters",
+		ruleEmail:    "must be a valid email address",
+		rulePositive: "must be a positive integer",
+		ruleMaxBytes: "must be at most %d bytes",
+		ruleLetter:   "must contain a letter",
+		ruleDigit:    "must contain a digit",
+		ruleSymbol:   "must contain a symbol",
+	},
+	TraditionalChinese: {
+		ruleInvalid:  "請求內容不正確",
+		ruleRequired: "為必填欄位",
+		ruleMinLen:   "至少需要 %d 個字元",
+		ruleMaxLen:   "最多只能有 %d 個字元",
+		ruleEmail:    "必須是有效的 email 地址",
+		rulePositive: "必須是正整數",
+		ruleMaxBytes: "最多只能有 %d bytes",
+		ruleLetter:   "必須包含英文字母",
+		ruleDigit:    "必須包含數字",
+		ruleSymbol:   "必須包含符號",
+	},
+}
+
+// 順序必須與 Locale 常數相同
+var matcher = language.NewMatcher([]language.Tag{language.English, language.TraditionalChinese})
+
+func (l Locale) format(v violation) string {
+	msg := messages[l][v.rule]
+	if v.limit > 0 {
+		return fmt.Sprintf(msg, v.limit)
+	}
+	return msg
+}
+
+// ParseLocale 解析 Accept-Language，例如 "zh-TW,zh;q=0.9,en;q=0.8"，無法辨識時為英文
+func ParseLocale(acceptLanguage string) Locale {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return English
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return English
+	}
+	return Locale(index)
+}
+
+type localeKey struct{}
+
+func NewContext(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+func LocaleFromContext(ctx context.Context) Locale {
+	locale, _ := ctx.Value(localeKey{}).(Locale)
+	return locale
+}
+
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := ParseLocale(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), locale))
+		c.Next()
+	}
+}
+
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(incoming(ctx), req)
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, grpcutil.WithContext(ss, incoming(ss.Context())))
+	}
+}
+
+// incoming 依 metadata 的 accept-language 設定語系
+func incoming(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("accept-language"); len(values) > 0 {
+			return NewContext(ctx, ParseLocale(values[0]))
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,132 @@
+// Package validate 依照 proto 欄位上的 (validate.field) 規則檢查請求，
+// 錯誤訊息依 ctx 中的語系 (英文或繁體中文) 產生
+package validate
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Request 檢查 msg 所有欄位的規則，有錯誤時回傳包含每個欄位錯誤的 apperr.Invalid
+func Request(ctx context.Context, msg proto.Message) error {
+	locale := LocaleFromContext(ctx)
+
+	var violations []apperr.Violation
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		rules, ok := proto.GetExtension(fd.Options(), pb.E_Field).(*pb.FieldRules)
+		if !ok || rules == nil {
+			continue
+		}
+		// optional 欄位沒帶代表不修改，不需要檢查
+		if fd.HasPresence() && !m.Has(fd) {
+			continue
+		}
+		for _, v := range check(rules, fd, m.Get(fd)) {
+			violations = append(violations, apperr.Violation{Field: fd.TextName(), Message: locale.format(v)})
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	err := apperr.Invalid(violations...)
+	err.Message = locale.format(violation{rule: ruleInvalid})
+	return err
+}
+
+// violation 是違反的規則與參數，訊息等到知道語系後才產生
+type violation struct {
+	rule  rule
+	limit uint32
+}
+
+func check(rules *pb.FieldRules, fd protoreflect.FieldDescriptor, value protoreflect.Value) []violation {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return checkString(rules, value.String())
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+		if rules.Positive && value.Int() <= 0 {
+			return []violation{{rule: rulePositive}}
+		}
+	}
+	return nil
+}
+
+func checkString(rules *pb.FieldRules, s string) []violation {
+	if strings.TrimSpace(s) == "" {
+		if rules.Required {
+			return []violation{{rule: ruleRequired}}
+		}
+		return nil
+	}
+
+	var vs []violation
+	length := uint32(utf8.RuneCountInString(s))
+	if rules.MinLen > 0 && length < rules.MinLen {
+		vs = append(vs, violation{rule: ruleMinLen, limit: rules.MinLen})
+	}
+	if rules.MaxLen > 0 && length > rules.MaxLen {
+		vs = append(vs, violation{rule: ruleMaxLen, limit: rules.MaxLen})
+	}
+	if rules.Email && !isEmail(s) {
+		vs = append(vs, violation{rule: ruleEmail})
+	}
+	if p := rules.Password; p != nil {
+		vs = append(vs, checkPassword(p, s, length)...)
+	}
+	return vs
+}
+
+func checkPassword(p *pb.PasswordRules, s string, length uint32) []violation {
+	var vs []violation
+	if p.MinLen > 0 && length < p.MinLen {
+		vs = append(vs, violation{rule: ruleMinLen, limit: p.MinLen})
+	}
+	if p.MaxBytes > 0 && uint32(len(s)) > p.MaxBytes {
+		vs = append(vs, violation{rule: ruleMaxBytes, limit: p.MaxBytes})
+	}
+
+	var letter, digit, symbol bool
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if p.RequireLetter && !letter {
+		vs = append(vs, violation{rule: ruleLetter})
+	}
+	if p.RequireDigit && !digit {
+		vs = append(vs, violation{rule: ruleDigit})
+	}
+	if p.RequireSymbol && !symbol {
+		vs = append(vs, violation{rule: ruleSymbol})
+	}
+	return vs
+}
+
+// isEmail 只接受單純的 user@domain，不接受 "Name <user@domain>" 這類寫法
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	_, domain, _ := strings.Cut(s, "@")
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".")
+}
@@ -0,0 +1,84 @@
+package validate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRequest(t *testing.T) {
+	str := proto.String
+
+	tests := []struct {
+		name string
+		req  proto.Message
+		want string // field:message，以 ; 分隔
+	}{
+		{"valid create", &pb.CreateUserRequest{Name: "Alice", Email: "alice@example.com", Password: "alice123"}, ""},
+		{"missing everything", &pb.CreateUserRequest{},
+			"name:is required;email:is required;password:is required"},
+		{"blank name", &pb.CreateUserRequest{Name: " \t", Email: "a@b.co", Password: "abcdefg1"}, "name:is required"},
+		{"long name", &pb.CreateUserRequest{Name: strings.Repeat("名", 101), Email: "a@b.co", Password: "abcdefg1"},
+			"name:must be at most 100 characters"},
+		{"display name email", &pb.CreateUserRequest{Name: "a", Email: "A <a@b.co>", Password: "abcdefg1"},
+			"email:must be a valid email address"},
+		{"email without domain dot", &pb.CreateUserRequest{Name: "a", Email: "a@localhost", Password: "abcdefg1"},
+			"email:must be a valid email address"},
+		{"weak password", &pb.CreateUserRequest{Name: "a", Email: "a@b.co", Password: "abc"},
+			"password:must be at least 8 characters;password:must contain a digit"},
+		{"digits only", &pb.CreateUserRequest{Name: "a", Email: "a@b.co", Password: "12345678"},
+			"password:must contain a letter"},
+		{"password over bcrypt limit", &pb.CreateUserRequest{Name: "a", Email: "a@b.co", Password: strings.Repeat("a1", 37)},
+			"password:must be at most 72 bytes"},
+
+		{"patch without fields", &pb.UpdateUserRequest{Id: 1}, ""},
+		{"patch empty name", &pb.UpdateUserRequest{Id: 1, Name: str("")}, "name:is required"},
+		{"patch bad email", &pb.UpdateUserRequest{Id: 1, Email: str("nope")}, "email:must be a valid email address"},
+		{"invalid id", &pb.GetUserRequest{Id: 0}, "id:must be a positive integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Request(context.Background(), tt.req)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			e := apperr.As(err)
+			if e == nil || e.Kind != apperr.Validation {
+				t.Fatalf("error = %v, want a validation error", err)
+			}
+			var got []string
+			for _, v := range e.Violations {
+				got = append(got, v.Field+":"+v.Message)
+			}
+			if strings.Join(got, ";") != tt.want {
+				t.Errorf("violations = %s, want %s", strings.Join(got, ";"), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	tests := map[string]Locale{
+		"":                        English,
+		"en-US":                   English,
+		"zh-TW":                   TraditionalChinese,
+		"zh-Hant":                 TraditionalChinese,
+		"fr;q=0.9, zh-TW;q=0.8":   TraditionalChinese,
+		"de":                      English,
+		"invalid;;;language=tag=": English,
+	}
+	for header, want := range tests {
+		if got := ParseLocale(header); got != want {
+			t.Errorf("ParseLocale(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
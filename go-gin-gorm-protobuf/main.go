@@ -1,27 +1,142 @@
 package main
 
 import (
-	"github.com/gin-gonic/gin"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/go-gin-gorm-protobuf/config"
-	"github.com/go-gin-gorm-protobuf/internal/controllers"
-	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	"github.com/go-gin-gorm-protobuf/internal/migrations"
+	"github.com/go-gin-gorm-protobuf/internal/repository"
+	"github.com/go-gin-gorm-protobuf/internal/server"
 	"github.com/go-gin-gorm-protobuf/internal/services"
+	"github.com/go-gin-gorm-protobuf/internal/tlsutil"
+	"github.com/go-gin-gorm-protobuf/service"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
-	r := gin.Default()
-
-	config.ConnectDatabase()
-	models.Migrate(config.DB)
+	// 例如 go run . -config config.yaml migrate up
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
 
-	userService := &services.UserService{DB: config.DB}
-	userController := &controllers.UserController{Service: userService}
+	config.ConnectDatabase(cfg.Database)
 
-	r.GET("/users", userController.GetUser)
-	r.POST("/users", userController.CreateUser)
-	r.DELETE("/users/:id", userController.DeleteUser)
-	err := r.Run(":8000")
+	sqlDB, err := config.DB.DB()
 	if err != nil {
+		log.Fatalf("failed to get database handle: %v", err)
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(migrator, args[1:]); err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
+
+	// schema 版本不一致時拒絕啟動，需先執行 migrate up
+	if err := migrator.Check(context.Background()); err != nil {
+		log.Fatalf("%v, run `%s migrate up` first", err, os.Args[0])
+	}
+
+	logger := newLogger(cfg.Server.LogFormat)
+	// apperr 等沒有拿到 logger 的 package 用 slog 的預設值，輸出到同一個地方
+	slog.SetDefault(logger)
+
+	var users repository.UserRepository = &repository.GormUserRepository{DB: config.DB}
+	if cfg.Cache.Enabled() {
+		// timeout 要短，Redis 有問題時不能拖慢請求，失敗的請求會直接改讀資料庫
+		rdb := redis.NewClient(&redis.Options{
+			Addr:         cfg.Cache.RedisAddr,
+			Password:     cfg.Cache.RedisPassword,
+			DB:           cfg.Cache.RedisDB,
+			DialTimeout:  time.Second,
+			ReadTimeout:  200 * time.Millisecond,
+			WriteTimeout: 200 * time.Millisecond,
+			MaxRetries:   -1,
+		})
+		defer rdb.Close()
+		users = repository.NewCachedUserRepository(users, rdb, repository.CacheOptions{
+			UserTTL:     cfg.Cache.UserTTL,
+			ListTTL:     cfg.Cache.ListTTL,
+			NegativeTTL: cfg.Cache.NegativeTTL,
+			Logger:      logger,
+		})
+	}
+	userService := &services.UserService{Users: users}
+	tokens := auth.NewTokenManager(jwtSecret(cfg.Auth), cfg.Auth.AccessTTL, cfg.Auth.RefreshTTL)
+
+	// SIGTERM (k8s / docker stop) 或 Ctrl+C 時等待進行中的請求完成再結束
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var tlsConfig *tls.Config
+	if cfg.Server.TLS.Enabled() {
+		reloader, err := tlsutil.NewReloader(tlsutil.Options{
+			CertFile:          cfg.Server.TLS.CertFile,
+			KeyFile:           cfg.Server.TLS.KeyFile,
+			ClientCAFile:      cfg.Server.TLS.ClientCAFile,
+			RequireClientCert: cfg.Server.TLS.ClientAuth == "require",
+			Logger:            logger,
+		})
+		if err != nil {
+			log.Fatalf("invalid TLS configuration: %v", err)
+		}
+		go reloader.Watch(ctx, cfg.Server.TLS.ReloadInterval)
+		tlsConfig = reloader.Config()
+	}
+
+	srv, err := server.New(server.Services{
+		Users: &service.Server{Service: userService},
+		Auth:  &service.AuthServer{Service: userService, Tokens: tokens},
+	}, tokens, server.Options{
+		Logger:          logger,
+		RequestTimeout:  cfg.Server.RequestTimeout,
+		ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		Reflection:      cfg.Server.Reflection,
+		TLS:             tlsConfig,
+		Ready:           sqlDB.PingContext,
+	})
+	if err != nil {
+		log.Fatalf("failed to create server: %v", err)
+	}
+
+	// REST (/users、/auth) 與 gRPC 共用同一個位址
+	log.Printf("Server is running on %s...", cfg.Server.Addr)
+	if err := srv.Run(ctx, cfg.Server.Addr); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+}
+
+func newLogger(format string) *slog.Logger {
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	}
+	return slog.New(slog.NewTextHandler(os.Stdout, nil))
+}
+
+// jwtSecret 沒有設定時用隨機值，重啟後所有 token 都會失效
+func jwtSecret(cfg config.AuthConfig) []byte {
+	if cfg.JWTSecret != "" {
+		return []byte(cfg.JWTSecret)
+	}
+	log.Println("auth.jwt_secret is not set, using a random secret")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("failed to generate jwt secret: %v", err)
+	}
+	return secret
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// cli 是一次子命令執行的狀態，連線與輸出參數每個子命令都有
+type cli struct {
+	name   string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	addr    string
+	token   string
+	timeout time.Duration
+	output  string
+
+	tls        bool
+	caCert     string
+	cert       string
+	key        string
+	serverName string
+}
+
+// flags 建立子命令的 FlagSet 並註冊共用的連線與輸出參數
+func (c *cli) flags(args string) *flag.FlagSet {
+	fs := flag.NewFlagSet("users "+c.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: proto_client users %s [flags] %s\n\nFlags:\n", c.name, args)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&c.addr, "addr", envOr("USER_SERVICE_ADDR", "127.0.0.1:8000"), "server address (env USER_SERVICE_ADDR)")
+	fs.StringVar(&c.token, "token", os.Getenv("USER_SERVICE_TOKEN"), "access token from /auth/login (env USER_SERVICE_TOKEN)")
+	fs.DurationVar(&c.timeout, "timeout", 15*time.Second, "deadline for the whole command, 0 for none")
+	fs.StringVar(&c.output, "o", "table", "output format: table, json or yaml")
+
+	fs.BoolVar(&c.tls, "tls", false, "connect with TLS using the system roots (implied by -ca-cert and -cert)")
+	fs.StringVar(&c.caCert, "ca-cert", "", "PEM file with the CA that signed the server certificate")
+	fs.StringVar(&c.cert, "cert", "", "PEM client certificate for mutual TLS")
+	fs.StringVar(&c.key, "key", "", "PEM private key of -cert")
+	fs.StringVar(&c.serverName, "server-name", "", "override the server name checked against the certificate")
+	return fs
+}
+
+// parse 解析參數並檢查位置參數的數量
+func (c *cli) parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &exitError{code: exitUsage, err: err}
+	}
+	switch c.output {
+	case "table", "json", "yaml":
+	default:
+		return usageErrorf("unknown output format %q", c.output)
+	}
+	if fs.NArg() != nargs {
+		fs.Usage()
+		return usageErrorf("expected %d argument(s), got %d", nargs, fs.NArg())
+	}
+	return nil
+}
+
+// connect 建立連線並回傳帶有 token 與 deadline 的 ctx，用完要呼叫 close
+func (c *cli) connect(ctx context.Context) (pb.UserServiceClient, context.Context, func(), error) {
+	creds, err := c.credentials()
+	if err != nil {
+		return nil, nil, nil, &exitError{code: exitConfig, err: err}
+	}
+	conn, err := grpc.NewClient(c.addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, nil, nil, usageErrorf("invalid address %q: %v", c.addr, err)
+	}
+
+	cancel := func() {}
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+	}
+	return pb.NewUserServiceClient(conn), ctx, func() {
+		cancel()
+		conn.Close()
+	}, nil
+}
+
+func (c *cli) credentials() (credentials.TransportCredentials, error) {
+	if !c.tls && c.caCert == "" && c.cert == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	config := &tls.Config{ServerName: c.serverName, MinVersion: tls.VersionTLS12}
+	if c.caCert != "" {
+		pem, err := os.ReadFile(c.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("read CA certificate: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.caCert)
+		}
+	}
+	if (c.cert == "") != (c.key == "") {
+		return nil, errors.New("-cert and -key must be used together")
+	}
+	if c.cert != "" {
+		cert, err := tls.LoadX509KeyPair(c.cert, c.key)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+func (c *cli) printer() printer {
+	return printer{w: c.stdout, format: c.output}
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, usageErrorf("invalid user id %q", s)
+	}
+	return id, nil
+}
+
+func envOr(name, fallback string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return fallback
+}
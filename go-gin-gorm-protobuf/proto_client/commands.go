@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/go-gin-gorm-protobuf/proto"
+)
+
+var commands = map[string]func(ctx context.Context, c *cli, args []string) error{
+	"create": createUser,
+	"get":    getUser,
+	"list":   listUsers,
+	"update": updateUser,
+	"delete": deleteUser,
+	"import": importUsers,
+}
+
+func createUser(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("")
+	req := &pb.CreateUserRequest{}
+	fs.StringVar(&req.Name, "name", "", "user name")
+	fs.StringVar(&req.Email, "email", "", "email address")
+	fs.StringVar(&req.Password, "password", "", "password")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	client, ctx, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	resp, err := client.CreateUser(ctx, req)
+	if err != nil {
+		return err
+	}
+	return c.printer().users(resp.User)
+}
+
+func getUser(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("ID")
+	if err := c.parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	client, ctx, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	resp, err := client.GetUser(ctx, &pb.GetUserRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	return c.printer().users(resp.User)
+}
+
+func listUsers(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("")
+	req := &pb.ListUsersRequest{}
+	limit := fs.Int("limit", 20, "page size, at most 100")
+	fs.StringVar(&req.PageToken, "cursor", "", "next_cursor of the previous page")
+	fs.StringVar(&req.NamePrefix, "name-prefix", "", "only users whose name starts with this")
+	fs.StringVar(&req.EmailPrefix, "email-prefix", "", "only users whose email starts with this")
+	fs.StringVar(&req.Sort, "sort", "", "id, name or email, prefix - for descending")
+	all := fs.Bool("all", false, "follow next_cursor until the last page")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	req.PageSize = int32(*limit)
+
+	client, ctx, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	result := &pb.ListUsersResponse{}
+	for {
+		resp, err := client.ListUsers(ctx, req)
+		if err != nil {
+			return err
+		}
+		result.Users = append(result.Users, resp.Users...)
+		result.NextCursor = resp.NextCursor
+		if !*all || resp.NextCursor == "" {
+			break
+		}
+		req.PageToken = resp.NextCursor
+	}
+
+	if result.NextCursor != "" && c.output == "table" {
+		defer fmt.Fprintf(c.stderr, "more users: -cursor %s\n", result.NextCursor)
+	}
+	return c.printer().list(result)
+}
+
+func updateUser(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("ID")
+	name := fs.String("name", "", "new user name")
+	email := fs.String("email", "", "new email address")
+	password := fs.String("password", "", "new password")
+	if err := c.parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// 只送出命令列上有出現的欄位，其他欄位保持不變
+	req := &pb.UpdateUserRequest{Id: id}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			req.Name = name
+		case "email":
+			req.Email = email
+		case "password":
+			req.Password = password
+		}
+	})
+	if req.Name == nil && req.Email == nil && req.Password == nil {
+		return usageErrorf("nothing to update, set -name, -email or -password")
+	}
+
+	client, ctx, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	resp, err := client.UpdateUser(ctx, req)
+	if err != nil {
+		return err
+	}
+	return c.printer().users(resp.User)
+}
+
+func deleteUser(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("ID")
+	if err := c.parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	client, ctx, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	resp, err := client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	return c.printer().print(resp, func(w io.Writer) {
+		fmt.Fprintf(w, "deleted user %d\n", id)
+	})
+}
+
+// importUsers 先讀完並解析整個檔案才開始上傳，格式錯誤的檔案不會匯入任何一筆
+func importUsers(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("FILE")
+	format := fs.String("format", "", "csv or jsonl, defaults to the file extension (csv for stdin)")
+	if err := c.parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = "csv"
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".jsonl" || ext == ".ndjson" {
+			*format = "jsonl"
+		}
+	}
+	if *format != "csv" && *format != "jsonl" {
+		return usageErrorf("unknown input format %q", *format)
+	}
+
+	in := c.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return &exitError{code: exitNoInput, err: err}
+		}
+		defer f.Close()
+		in = f
+	}
+	rows, err := readRows(in, *format)
+	if err != nil {
+		return &exitError{code: exitData, err: fmt.Errorf("%s: %w", path, err)}
+	}
+
+	client, ctx, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	stream, err := client.ImportUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := stream.Send(row); err != nil {
+			// server 提前結束時真正的錯誤要由 CloseAndRecv 取得
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+
+	if err := c.printer().imported(resp); err != nil {
+		return err
+	}
+	if resp.Failed > 0 {
+		return &exitError{code: exitData, err: fmt.Errorf("%d of %d rows were rejected", resp.Failed, len(rows))}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	pb "github.com/go-gin-gorm-protobuf/proto"
+)
+
+// readRows 解析 import 的輸入檔。
+// CSV 第一行是欄位名稱 name、email、password (順序不拘)；JSONL 每行一個 {"name", "email", "password"} 物件，空白行會略過
+func readRows(r io.Reader, format string) ([]*pb.CreateUserRequest, error) {
+	var rows []*pb.CreateUserRequest
+	var err error
+	if format == "jsonl" {
+		rows, err = readJSONL(r)
+	} else {
+		rows, err = readCSV(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no users to import")
+	}
+	return rows, nil
+}
+
+func readCSV(r io.Reader) ([]*pb.CreateUserRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "name", "email", "password":
+		default:
+			return nil, fmt.Errorf("line 1: unknown column %q, expected name, email and password", name)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("line 1: duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"name", "email", "password"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("line 1: missing column %q", name)
+		}
+	}
+
+	var rows []*pb.CreateUserRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, &pb.CreateUserRequest{
+			Name:     record[columns["name"]],
+			Email:    record[columns["email"]],
+			Password: record[columns["password"]],
+		})
+	}
+}
+
+func readJSONL(r io.Reader) ([]*pb.CreateUserRequest, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var rows []*pb.CreateUserRequest
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var row struct {
+			Name     string `json:"name"`
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("line %d: expected one JSON object per line", line)
+		}
+		rows = append(rows, &pb.CreateUserRequest{Name: row.Name, Email: row.Email, Password: row.Password})
+	}
+	return rows, scanner.Err()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadRows(t *testing.T) {
+	tests := []struct {
+		name, format, input string
+		want                []string // 每筆的 email
+		wantErr             string
+	}{
+		{
+			name:   "csv with reordered columns",
+			format: "csv",
+			input:  "email, password, name\na@example.com,password1,Alice\n\"b@example.com\",password2,\"Bob, Jr.\"\n",
+			want:   []string{"a@example.com", "b@example.com"},
+		},
+		{name: "csv missing column", format: "csv", input: "name,email\nAlice,a@example.com\n", wantErr: `missing column "password"`},
+		{name: "csv unknown column", format: "csv", input: "name,email,password,age\n", wantErr: `unknown column "age"`},
+		{name: "csv short record", format: "csv", input: "name,email,password\nAlice,a@example.com\n", wantErr: "line 2"},
+		{name: "csv header only", format: "csv", input: "name,email,password\n", wantErr: "no users"},
+		{
+			name:   "jsonl",
+			format: "jsonl",
+			input:  "{\"name\":\"Alice\",\"email\":\"a@example.com\",\"password\":\"password1\"}\n\n  {\"email\":\"b@example.com\"}\n",
+			want:   []string{"a@example.com", "b@example.com"},
+		},
+		{name: "jsonl unknown field", format: "jsonl", input: "{\"name\":\"Alice\"}\n{\"age\":3}\n", wantErr: `line 2: json: unknown field "age"`},
+		{name: "jsonl two objects", format: "jsonl", input: "{} {}\n", wantErr: "line 1: expected one JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readRows(strings.NewReader(tt.input), tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, row := range rows {
+				if row.Email != tt.want[i] {
+					t.Errorf("rows[%d].Email = %q, want %q", i, row.Email, tt.want[i])
+				}
+			}
+		})
+	}
+
+	rows, _ := readRows(strings.NewReader("email,password,name\na@example.com,password1,\"Bob, Jr.\"\n"), "csv")
+	if rows[0].Name != "Bob, Jr." || rows[0].Password != "password1" {
+		t.Errorf("columns mapped by header: %v", rows[0])
+	}
+}
@@ -1,39 +1,133 @@
+// proto_client 是 UserService 的 gRPC 命令列工具，例如
+//
+//	proto_client users list -addr 127.0.0.1:8000 -token $TOKEN -o yaml
+//	proto_client users import -token $TOKEN users.csv
+//
+// 執行 proto_client 不帶參數可以看到所有子命令、參數與結束代碼
 package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	pb "github.com/go-gin-gorm-protobuf/proto"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"log"
-	"time"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// 非 gRPC 錯誤的結束代碼，沿用 sysexits.h，不會和 gRPC status code (1-16) 重疊
+const (
+	exitUsage   = 64 // 參數錯誤
+	exitData    = 65 // 輸入檔格式錯誤，或 import 有資料被拒絕
+	exitNoInput = 66 // 輸入檔無法開啟
+	exitConfig  = 78 // TLS 憑證等設定錯誤
 )
 
+const usage = `Usage: proto_client users <command> [flags] [args]
+
+Commands:
+  create -name NAME -email EMAIL -password PASSWORD
+  get ID
+  list [-limit N] [-cursor C] [-name-prefix P] [-email-prefix P] [-sort S] [-all]
+  update ID [-name NAME] [-email EMAIL] [-password PASSWORD]
+  delete ID
+  import [-format csv|jsonl] FILE    (FILE - reads stdin)
+
+Every command accepts the connection and output flags, see proto_client users <command> -h.
+
+Exit status:
+  0      success
+  1-16   gRPC status code of the failed RPC, e.g. 5 NOT_FOUND, 7 PERMISSION_DENIED, 14 UNAVAILABLE
+  64     invalid command line
+  65     invalid input file, or import rejected some rows
+  66     input file cannot be opened
+  78     invalid TLS configuration
+`
+
+// exitError 讓錯誤帶著非 gRPC 的結束代碼
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &exitError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
 func main() {
-	log.Println("嘗試連接到 gRPC 伺服器...")
-	// 連接 gRPC 伺服器
-	conn, err := grpc.NewClient("127.0.0.1:50052", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run 執行一個子命令並回傳結束代碼
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 2 || args[0] != "users" {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	cmd, ok := commands[args[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[1], usage)
+		return exitUsage
+	}
+
+	c := &cli{name: args[1], stdin: stdin, stdout: stdout, stderr: stderr}
+	err := cmd(ctx, c, args[2:])
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
 	if err != nil {
-		log.Fatalf("無法連接到伺服器: %v", err)
+		printError(stderr, err)
 	}
-	log.Println("成功建立連接！")
-	defer conn.Close()
+	return exitCode(err)
+}
 
-	client := pb.NewUserServiceClient(conn)
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	// 非 status 的錯誤是 codes.Unknown
+	return int(status.Code(err))
+}
 
-	// 創建用戶
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
-	defer cancel()
-	createResp, err := client.CreateUser(ctx, &pb.CreateUserRequest{Name: "Alice", Age: 25})
-	if err != nil {
-		log.Fatalf("CreateUser failed: %v", err)
+// printError 印出 status 的錯誤代碼、欄位錯誤與 request ID，方便對照 server 的 log
+func printError(w io.Writer, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return
 	}
-	fmt.Println("Created User ID:", createResp.UserId)
-	// 取得用戶
-	getResp, err := client.GetUser(ctx, &pb.GetUserRequest{UserId: createResp.UserId})
-	if err != nil {
-		log.Fatalf("GetUser failed: %v", err)
+
+	reason := st.Code().String()
+	var lines []string
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			reason = d.Reason
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				lines = append(lines, fmt.Sprintf("  %s: %s", v.Field, v.Description))
+			}
+		case *errdetails.RequestInfo:
+			lines = append(lines, "  request id: "+d.RequestId)
+		}
+	}
+	fmt.Fprintf(w, "error: %s (%s): %s\n", st.Code(), reason, st.Message())
+	if len(lines) > 0 {
+		fmt.Fprintln(w, strings.Join(lines, "\n"))
 	}
-	fmt.Println("User Info:", getResp)
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	"github.com/go-gin-gorm-protobuf/internal/models"
+	"github.com/go-gin-gorm-protobuf/internal/repository"
+	"github.com/go-gin-gorm-protobuf/internal/server"
+	"github.com/go-gin-gorm-protobuf/internal/services"
+	"github.com/go-gin-gorm-protobuf/internal/tlsutil"
+	"github.com/go-gin-gorm-protobuf/internal/tlsutil/tlstest"
+	"github.com/go-gin-gorm-protobuf/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	models.PasswordCost = bcrypt.MinCost
+	os.Exit(m.Run())
+}
+
+// startServer 啟動使用記憶體 repository 的完整 server，回傳位址與管理員的 access token
+func startServer(t *testing.T, opts server.Options) (addr, token string) {
+	t.Helper()
+	repo := repository.NewMemoryUserRepository()
+	admin := models.User{Name: "admin", Email: "admin@example.com", Role: models.RoleAdmin}
+	if err := repo.Create(context.Background(), &admin); err != nil {
+		t.Fatal(err)
+	}
+
+	userService := &services.UserService{Users: repo}
+	opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	tokens := auth.NewTokenManager([]byte("test-secret-test-secret-test-secret"), time.Minute, time.Hour)
+	srv, err := server.New(server.Services{
+		Users: &service.Server{Service: userService},
+		Auth:  &service.AuthServer{Service: userService, Tokens: tokens},
+	}, tokens, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.Serve(ctx, lis)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	pair, err := tokens.Issue(admin.ID, admin.Role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lis.Addr().String(), pair.AccessToken
+}
+
+type result struct {
+	code           int
+	stdout, stderr string
+}
+
+func runCLI(t *testing.T, stdin string, args ...string) result {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return result{code, stdout.String(), stderr.String()}
+}
+
+func TestCommands(t *testing.T) {
+	addr, token := startServer(t, server.Options{})
+	conn := []string{"-addr", addr, "-token", token}
+	users := func(cmd string, args ...string) []string {
+		return append(append([]string{"users", cmd}, conn...), args...)
+	}
+
+	r := runCLI(t, "", users("create", "-o", "json", "-name", "Alice", "-email", "alice@example.com", "-password", "password1")...)
+	var created struct{ ID string }
+	if err := json.Unmarshal([]byte(r.stdout), &created); r.code != 0 || err != nil || created.ID != "2" {
+		t.Fatalf("create: %+v (%v)", r, err)
+	}
+
+	r = runCLI(t, "", users("update", "-name", "Alicia", "2")...)
+	if r.code != 0 || !strings.Contains(r.stdout, "Alicia") || !strings.Contains(r.stdout, "alice@example.com") {
+		t.Fatalf("update: %+v", r)
+	}
+
+	r = runCLI(t, "", users("get", "-o", "yaml", "2")...)
+	if r.code != 0 || !strings.Contains(r.stdout, "name: Alicia\n") || !strings.Contains(r.stdout, `id: "2"`) {
+		t.Fatalf("get: %+v", r)
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "users.jsonl")
+	jsonl := `{"name":"Bob","email":"bob@example.com","password":"password1"}
+{"name":"Dup","email":"alice@example.com","password":"password1"}
+{"name":"Carol","email":"carol@example.com","password":"short"}
+`
+	if err := os.WriteFile(file, []byte(jsonl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r = runCLI(t, "", users("import", file)...)
+	if r.code != exitData || !strings.Contains(r.stdout, "EMAIL_TAKEN") || !strings.Contains(r.stdout, "created 1, failed 2") {
+		t.Fatalf("import: %+v", r)
+	}
+
+	r = runCLI(t, "name,email,password\nDave,dave@example.com,password1\n", users("import", "-")...)
+	if r.code != 0 || !strings.Contains(r.stdout, "created 1, failed 0") {
+		t.Fatalf("import from stdin: %+v", r)
+	}
+
+	r = runCLI(t, "", users("list", "-limit", "2", "-all", "-sort", "-id")...)
+	if r.code != 0 || strings.Count(r.stdout, "\n") != 5 || !strings.Contains(r.stdout, "dave@example.com") {
+		t.Fatalf("list -all: %+v", r)
+	}
+
+	r = runCLI(t, "", users("delete", "2")...)
+	if r.code != 0 || r.stdout != "deleted user 2\n" {
+		t.Fatalf("delete: %+v", r)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	addr, token := startServer(t, server.Options{})
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantStderr string
+	}{
+		{"no command", []string{"users"}, exitUsage, "Usage:"},
+		{"unknown command", []string{"users", "rename"}, exitUsage, "unknown command"},
+		{"bad flag", []string{"users", "list", "-nope"}, exitUsage, "not defined"},
+		{"help", []string{"users", "list", "-h"}, 0, "-email-prefix"},
+		{"bad id", []string{"users", "get", "-addr", addr, "abc"}, exitUsage, "invalid user id"},
+		{"bad output", []string{"users", "get", "-o", "xml", "1"}, exitUsage, "unknown output format"},
+		{"missing file", []string{"users", "import", "-addr", addr, "/nonexistent.csv"}, exitNoInput, "no such file"},
+		{"missing key", []string{"users", "get", "-cert", "client.pem", "1"}, exitConfig, "-cert and -key"},
+		{"not found", []string{"users", "get", "-addr", addr, "-token", token, "99"}, 5, "USER_NOT_FOUND"},
+		{"unauthenticated", []string{"users", "list", "-addr", addr}, 16, "UNAUTHENTICATED"},
+		{
+			"invalid argument",
+			[]string{"users", "create", "-addr", addr, "-name", "x", "-email", "bad", "-password", "password1"},
+			3, "  email: ",
+		},
+		{"unavailable", []string{"users", "get", "-addr", "127.0.0.1:1", "-timeout", "5s", "1"}, 14, "Unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := runCLI(t, "", tt.args...)
+			if r.code != tt.want || !strings.Contains(r.stderr, tt.wantStderr) {
+				t.Errorf("exit %d, want %d, stderr:\n%s", r.code, tt.want, r.stderr)
+			}
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	serverCA := tlstest.NewCA(t, "server")
+	clientCA := tlstest.NewCA(t, "clients")
+	dir := t.TempDir()
+	files := func(name string) (string, string) {
+		return filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	}
+	serverCert, serverKey := files("server")
+	tlstest.WriteFiles(t, serverCA.Server(t), serverCert, serverKey)
+	clientCert, clientKey := files("client")
+	tlstest.WriteFiles(t, clientCA.Client(t, "ops", "admin"), clientCert, clientKey)
+
+	reloader, err := tlsutil.NewReloader(tlsutil.Options{CertFile: serverCert, KeyFile: serverKey, ClientCAFile: clientCA.CertFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := startServer(t, server.Options{TLS: reloader.Config()})
+
+	// 憑證的 O 是 admin，不需要 token
+	r := runCLI(t, "", "users", "list", "-addr", addr, "-ca-cert", serverCA.CertFile, "-cert", clientCert, "-key", clientKey)
+	if r.code != 0 || !strings.Contains(r.stdout, "admin@example.com") {
+		t.Fatalf("list with a client certificate: %+v", r)
+	}
+
+	r = runCLI(t, "", "users", "list", "-addr", addr, "-ca-cert", serverCA.CertFile)
+	if r.code != 16 {
+		t.Fatalf("list without a client certificate: %+v", r)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/go-gin-gorm-protobuf/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+// printer 依 -o 輸出回應，json 與 yaml 使用 protobuf 的 JSON 對應 (欄位為 lowerCamelCase)
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// print 輸出 msg，table 格式時改由 table 寫入對齊好的欄位
+func (p printer) print(msg proto.Message, table func(w io.Writer)) error {
+	switch p.format {
+	case "table":
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	case "json":
+		data, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", data)
+		return err
+	default:
+		data, err := toYAML(msg)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(data)
+		return err
+	}
+}
+
+func (p printer) users(user *pb.User) error {
+	return p.print(user, func(w io.Writer) {
+		userTable(w, []*pb.User{user})
+	})
+}
+
+func (p printer) list(resp *pb.ListUsersResponse) error {
+	return p.print(resp, func(w io.Writer) {
+		userTable(w, resp.Users)
+	})
+}
+
+// imported 在 table 格式只列出失敗的資料，成功的筆數放在最後一行
+func (p printer) imported(resp *pb.ImportUsersResponse) error {
+	return p.print(resp, func(w io.Writer) {
+		if resp.Failed > 0 {
+			fmt.Fprintln(w, "ROW\tREASON\tERROR")
+			for _, result := range resp.Results {
+				if result.Error != "" {
+					fmt.Fprintf(w, "%d\t%s\t%s\n", result.Row, result.Reason, result.Error)
+				}
+			}
+		}
+		fmt.Fprintf(w, "created %d, failed %d\n", resp.Created, resp.Failed)
+	})
+}
+
+func userTable(w io.Writer, users []*pb.User) {
+	fmt.Fprintln(w, "ID\tNAME\tEMAIL\tROLE\tCREATED\tUPDATED")
+	for _, u := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", u.Id, u.Name, u.Email, u.Role, formatTime(u.CreatedAt), formatTime(u.UpdatedAt))
+	}
+}
+
+func formatTime(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return "-"
+	}
+	return ts.AsTime().Local().Format(time.DateTime)
+}
+
+// toYAML 經由 protojson 轉成 YAML，保留 proto 的欄位順序
+func toYAML(msg proto.Message) ([]byte, error) {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	blockStyle(&doc)
+	return yaml.Marshal(&doc)
+}
+
+// blockStyle 把 JSON 解析出來的 flow style 改回一般的 YAML 區塊格式，
+// 字串若會被誤認成其他型別，yaml 會自動加上引號
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/go-gin-gorm-protobuf/internal/auth"
+	"github.com/go-gin-gorm-protobuf/internal/services"
+	pb "github.com/go-gin-gorm-protobuf/proto"
+)
+
+var errUserGone = apperr.New(apperr.Unauthorized, "USER_GONE", "user no longer exists")
+
+// AuthServer 以 email/密碼登入並簽發 JWT
+type AuthServer struct {
+	pb.UnimplementedAuthServiceServer
+	Service *services.UserService
+	Tokens  *auth.TokenManager
+}
+
+func (s *AuthServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.TokenResponse, error) {
+	user, err := s.Service.Authenticate(ctx, req.Email, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(user)
+}
+
+func (s *AuthServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.TokenResponse, error) {
+	claims, err := s.Tokens.ConsumeRefresh(req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新讀取使用者，角色變更或帳號刪除會在下一次 refresh 生效
+	user, err := s.Service.GetUser(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			return nil, errUserGone
+		}
+		return nil, err
+	}
+	return s.issue(user)
+}
+
+func (s *AuthServer) issue(user *pb.User) (*pb.TokenResponse, error) {
+	pair, err := s.Tokens.Issue(uint(user.Id), user.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TokenResponse{
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(pair.ExpiresIn.Seconds()),
+	}, nil
+}
@@ -1,29 +1,223 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"io"
+	"slices"
+
+	"github.com/go-gin-gorm-protobuf/internal/apperr"
+	"github.com/go-gin-gorm-protobuf/internal/services"
+	"github.com/go-gin-gorm-protobuf/internal/validate"
 	pb "github.com/go-gin-gorm-protobuf/proto"
-	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 )
 
-var ServerIns pb.UserServiceServer = Server{}
-var persons map[string]*pb.GetUserResponse = make(map[string]*pb.GetUserResponse)
-
+// Server 將 gRPC 請求轉給與 REST 共用的 services.UserService，請求先依 user.proto 的規則驗證。
+// 錯誤直接回傳 *apperr.Error，由 interceptor 或 gateway 轉成 gRPC status / problem+json
 type Server struct {
 	pb.UnimplementedUserServiceServer
+	Service *services.UserService
+}
+
+func (s *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	if err := validate.Request(ctx, req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Service.GetUser(ctx, uint(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetUserResponse{User: user}, nil
+}
+
+func (s *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := validate.Request(ctx, req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Service.CreateUser(ctx, req.Name, req.Email, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateUserResponse{User: user}, nil
+}
+
+func (s *Server) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	page, err := s.Service.ListUsers(ctx, services.ListOptions{
+		Limit:       int(req.PageSize),
+		Cursor:      req.PageToken,
+		NamePrefix:  req.NamePrefix,
+		EmailPrefix: req.EmailPrefix,
+		Sort:        req.Sort,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListUsersResponse{Users: page.Users, NextCursor: page.NextCursor}, nil
+}
+
+func (s *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	if err := validate.Request(ctx, req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Service.UpdateUser(ctx, uint(req.Id), services.UserUpdate{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateUserResponse{User: user}, nil
+}
+
+func (s *Server) ReplaceUser(ctx context.Context, req *pb.ReplaceUserRequest) (*pb.ReplaceUserResponse, error) {
+	if err := validate.Request(ctx, req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Service.UpdateUser(ctx, uint(req.Id), services.UserUpdate{
+		Name:     &req.Name,
+		Email:    &req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReplaceUserResponse{User: user}, nil
 }
 
-func (s Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
-	person := persons[req.UserId]
-	return person, nil
+func (s *Server) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	if err := validate.Request(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.Service.DeleteUser(ctx, uint(req.Id)); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteUserResponse{}, nil
 }
 
-func (s Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
-	userID := uuid.NewString()
-	persons[userID] = &pb.GetUserResponse{
-		UserId: userID,
-		Name:   req.Name,
-		Age:    req.Age,
+func (s *Server) RestoreUser(ctx context.Context, req *pb.RestoreUserRequest) (*pb.RestoreUserResponse, error) {
+	if err := validate.Request(ctx, req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.Service.RestoreUser(ctx, uint(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RestoreUserResponse{User: user}, nil
+}
+
+func (s *Server) ListUserHistory(ctx context.Context, req *pb.ListUserHistoryRequest) (*pb.ListUserHistoryResponse, error) {
+	if err := validate.Request(ctx, req); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.Service.ListUserHistory(ctx, uint(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListUserHistoryResponse{Entries: entries}, nil
+}
+
+func (s *Server) ImportUsers(stream pb.UserService_ImportUsersServer) error {
+	ctx := stream.Context()
+	resp := &pb.ImportUsersResponse{}
+
+	var batch []services.NewUser
+	var rows []int32
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := s.Service.ImportUsers(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for i, result := range results {
+			resp.Results = append(resp.Results, importResult(ctx, rows[i], result.User, result.Err))
+		}
+		batch, rows = batch[:0], rows[:0]
+		return nil
+	}
+
+	for row := int32(1); ; row++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := validate.Request(ctx, req); err != nil {
+			resp.Results = append(resp.Results, importResult(ctx, row, nil, err))
+			continue
+		}
+		batch = append(batch, services.NewUser{Name: req.Name, Email: req.Email, Password: req.Password})
+		rows = append(rows, row)
+		if len(batch) == services.ImportBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	slices.SortFunc(resp.Results, func(a, b *pb.ImportUserResult) int { return cmp.Compare(a.Row, b.Row) })
+	for _, result := range resp.Results {
+		if result.Error == "" {
+			resp.Created++
+		} else {
+			resp.Failed++
+		}
+	}
+	return stream.SendAndClose(resp)
+}
+
+// importResult 的錯誤內容與 gRPC status 相同，未分類的錯誤不會洩漏細節
+func importResult(ctx context.Context, row int32, user *pb.User, err error) *pb.ImportUserResult {
+	result := &pb.ImportUserResult{Row: row}
+	if err == nil {
+		result.Id = user.Id
+		return result
+	}
+
+	st := apperr.ToStatus(ctx, err)
+	result.Error = st.Message()
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			result.Reason = info.Reason
+		}
+	}
+	return result
+}
+
+func (s *Server) WatchUsers(req *pb.WatchUsersRequest, stream pb.UserService_WatchUsersServer) error {
+	sub := s.Service.Watch(req.Types)
+	defer sub.Close()
+	// 先送出 header，client 收到就代表已經訂閱，之後的事件不會漏掉
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-sub.Dropped():
+			return services.ErrWatcherTooSlow
+		case event := <-sub.Events():
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
 	}
-	return &pb.CreateUserResponse{UserId: userID}, nil
 }
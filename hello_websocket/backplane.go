@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+// Backplane 連接多個節點上的 Hub：轉送房間訊息與私訊，並記錄整個叢集的 presence
+// (哪個 member 在哪個節點上線、加入了哪些房間)。
+// 下線與離開房間只有在記錄的節點相同時才生效，member 換到別的節點重新連線時不會被舊節點清掉
+type Backplane interface {
+	// Subscribe 開始接收 Publish 的訊息，包含自己送出的
+	Subscribe(node string, deliver func(*pb.Relay))
+	Publish(ctx context.Context, relay *pb.Relay) error
+
+	SetOnline(ctx context.Context, node, member string, online bool) error
+	SetInRoom(ctx context.Context, node, room, member string, in bool) error
+	Online(ctx context.Context, member string) (bool, error)
+	// RoomMembers 回傳房間內所有節點上的成員，依 ID 排序
+	RoomMembers(ctx context.Context, room string) ([]string, error)
+}
+
+// LocalBackplane 是單一 process 內的 Backplane，預設只有一個節點；
+// 多個 Hub 共用同一個 LocalBackplane 時就像在同一個叢集裡
+type LocalBackplane struct {
+	mu          sync.RWMutex
+	subscribers map[string]func(*pb.Relay)
+	members     map[string]string            // member -> node
+	rooms       map[string]map[string]string // room -> member -> node
+}
+
+func NewLocalBackplane() *LocalBackplane {
+	return &LocalBackplane{
+		subscribers: make(map[string]func(*pb.Relay)),
+		members:     make(map[string]string),
+		rooms:       make(map[string]map[string]string),
+	}
+}
+
+func (b *LocalBackplane) Subscribe(node string, deliver func(*pb.Relay)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[node] = deliver
+}
+
+// Publish 直接呼叫每個節點的 deliver，呼叫時不持有 lock
+func (b *LocalBackplane) Publish(_ context.Context, relay *pb.Relay) error {
+	b.mu.RLock()
+	subscribers := make([]func(*pb.Relay), 0, len(b.subscribers))
+	for _, deliver := range b.subscribers {
+		subscribers = append(subscribers, deliver)
+	}
+	b.mu.RUnlock()
+
+	for _, deliver := range subscribers {
+		deliver(relay)
+	}
+	return nil
+}
+
+func (b *LocalBackplane) SetOnline(_ context.Context, node, member string, online bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	set(b.members, node, member, online)
+	return nil
+}
+
+func (b *LocalBackplane) SetInRoom(_ context.Context, node, room, member string, in bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rooms[room] == nil {
+		b.rooms[room] = make(map[string]string)
+	}
+	set(b.rooms[room], node, member, in)
+	if len(b.rooms[room]) == 0 {
+		delete(b.rooms, room)
+	}
+	return nil
+}
+
+// set 記錄 member 在 node 上，移除時只移除同一個 node 的記錄
+func set(nodes map[string]string, node, member string, present bool) {
+	if present {
+		nodes[member] = node
+	} else if nodes[member] == node {
+		delete(nodes, member)
+	}
+}
+
+func (b *LocalBackplane) Online(_ context.Context, member string) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.members[member]
+	return ok, nil
+}
+
+func (b *LocalBackplane) RoomMembers(_ context.Context, room string) ([]string, error) {
+	b.mu.RLock()
+	members := make([]string, 0, len(b.rooms[room]))
+	for member := range b.rooms[room] {
+		members = append(members, member)
+	}
+	b.mu.RUnlock()
+	sort.Strings(members)
+	return members, nil
+}
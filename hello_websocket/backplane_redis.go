@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+type RedisBackplaneOptions struct {
+	Prefix string // key 與 channel 的前綴，預設 "ws:"
+	// NodeTTL 是節點多久沒有心跳就當作離線，它的 member 與房間記錄一起失效，預設 30 秒
+	NodeTTL time.Duration
+}
+
+// removeIfNode 只有在 hash 裡記錄的節點相同時才刪除
+var removeIfNode = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) == ARGV[2] then
+	return redis.call("HDEL", KEYS[1], ARGV[1])
+end
+return 0`)
+
+// RedisBackplane 用 Redis pub/sub 轉送訊息，presence 存在 Redis 的 hash：
+//
+//	<prefix>relay          pub/sub channel，內容是 pb.Relay
+//	<prefix>node:<node>    節點的心跳，每 NodeTTL/3 更新一次
+//	<prefix>members        member -> node
+//	<prefix>room:<room>    member -> node
+//
+// 節點當掉時沒有機會清掉自己的記錄，所以讀取時會略過 (並刪除) 心跳已經過期的節點上的 member。
+// 訊息是 fire-and-forget，斷線期間 publish 的訊息會遺失
+type RedisBackplane struct {
+	redis redis.UniversalClient
+	opts  RedisBackplaneOptions
+
+	mu        sync.Mutex
+	nodes     map[string]*redis.PubSub
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func NewRedisBackplane(client redis.UniversalClient, opts RedisBackplaneOptions) *RedisBackplane {
+	if opts.Prefix == "" {
+		opts.Prefix = "ws:"
+	}
+	if opts.NodeTTL <= 0 {
+		opts.NodeTTL = 30 * time.Second
+	}
+	return &RedisBackplane{
+		redis: client,
+		opts:  opts,
+		nodes: make(map[string]*redis.PubSub),
+		stop:  make(chan struct{}),
+	}
+}
+
+func (b *RedisBackplane) channel() string            { return b.opts.Prefix + "relay" }
+func (b *RedisBackplane) nodeKey(node string) string { return b.opts.Prefix + "node:" + node }
+func (b *RedisBackplane) membersKey() string         { return b.opts.Prefix + "members" }
+func (b *RedisBackplane) roomKey(room string) string { return b.opts.Prefix + "room:" + room }
+
+// Subscribe 等到訂閱生效才回傳，連不上 Redis 時只記錄錯誤，go-redis 會在背景重新連線
+func (b *RedisBackplane) Subscribe(node string, deliver func(*pb.Relay)) {
+	ctx := context.Background()
+	pubsub := b.redis.Subscribe(ctx, b.channel())
+	if _, err := pubsub.Receive(ctx); err != nil {
+		fmt.Println("backplane subscribe failed:", err)
+	}
+	b.heartbeat(node)
+
+	b.mu.Lock()
+	b.nodes[node] = pubsub
+	b.mu.Unlock()
+
+	b.wg.Add(2)
+	go func() {
+		defer b.wg.Done()
+		for msg := range pubsub.Channel() {
+			relay := &pb.Relay{}
+			if err := proto.Unmarshal([]byte(msg.Payload), relay); err != nil {
+				fmt.Println("backplane received an invalid relay:", err)
+				continue
+			}
+			deliver(relay)
+		}
+	}()
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(b.opts.NodeTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.heartbeat(node)
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (b *RedisBackplane) heartbeat(node string) {
+	if err := b.redis.Set(context.Background(), b.nodeKey(node), time.Now().Unix(), b.opts.NodeTTL).Err(); err != nil {
+		fmt.Println("backplane heartbeat failed:", err)
+	}
+}
+
+func (b *RedisBackplane) Publish(ctx context.Context, relay *pb.Relay) error {
+	data, err := proto.Marshal(relay)
+	if err != nil {
+		return err
+	}
+	return b.redis.Publish(ctx, b.channel(), data).Err()
+}
+
+func (b *RedisBackplane) SetOnline(ctx context.Context, node, member string, online bool) error {
+	return b.set(ctx, b.membersKey(), node, member, online)
+}
+
+func (b *RedisBackplane) SetInRoom(ctx context.Context, node, room, member string, in bool) error {
+	return b.set(ctx, b.roomKey(room), node, member, in)
+}
+
+func (b *RedisBackplane) set(ctx context.Context, key, node, member string, present bool) error {
+	if present {
+		return b.redis.HSet(ctx, key, member, node).Err()
+	}
+	return removeIfNode.Run(ctx, b.redis, []string{key}, member, node).Err()
+}
+
+func (b *RedisBackplane) Online(ctx context.Context, member string) (bool, error) {
+	node, err := b.redis.HGet(ctx, b.membersKey(), member).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	alive, err := b.alive(ctx, map[string]bool{node: false})
+	return alive[node], err
+}
+
+func (b *RedisBackplane) RoomMembers(ctx context.Context, room string) ([]string, error) {
+	entries, err := b.redis.HGetAll(ctx, b.roomKey(room)).Result()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]bool)
+	for _, node := range entries {
+		nodes[node] = false
+	}
+	if nodes, err = b.alive(ctx, nodes); err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(entries))
+	for member, node := range entries {
+		if nodes[node] {
+			members = append(members, member)
+		} else if err := b.set(ctx, b.roomKey(room), node, member, false); err != nil {
+			fmt.Println("backplane cleanup failed:", err)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// alive 查詢 nodes 裡每個節點的心跳是否還在
+func (b *RedisBackplane) alive(ctx context.Context, nodes map[string]bool) (map[string]bool, error) {
+	if len(nodes) == 0 {
+		return nodes, nil
+	}
+	pipe := b.redis.Pipeline()
+	checks := make(map[string]*redis.IntCmd, len(nodes))
+	for node := range nodes {
+		checks[node] = pipe.Exists(ctx, b.nodeKey(node))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	for node, check := range checks {
+		nodes[node] = check.Val() == 1
+	}
+	return nodes, nil
+}
+
+// Close 停止訂閱與心跳，並刪除這個 backplane 上所有節點的心跳與 member 記錄，
+// 房間的記錄會在下次讀取時被清掉。只有第一次呼叫有作用，之後的呼叫直接回傳 nil
+func (b *RedisBackplane) Close() error {
+	var err error
+	b.closeOnce.Do(func() { err = b.close() })
+	return err
+}
+
+func (b *RedisBackplane) close() error {
+	b.mu.Lock()
+	close(b.stop)
+	nodes := b.nodes
+	b.nodes = nil
+	b.mu.Unlock()
+
+	ctx := context.Background()
+	var errs []error
+	for node, pubsub := range nodes {
+		errs = append(errs, pubsub.Close(), b.redis.Del(ctx, b.nodeKey(node)).Err())
+	}
+	b.wg.Wait()
+
+	members, err := b.redis.HGetAll(ctx, b.membersKey()).Result()
+	errs = append(errs, err)
+	for member, node := range members {
+		if _, ok := nodes[node]; ok {
+			errs = append(errs, b.set(ctx, b.membersKey(), node, member, false))
+		}
+	}
+	return errors.Join(errs...)
+}
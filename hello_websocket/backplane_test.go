@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+func newRedisBackplane(t *testing.T, mr *miniredis.Miniredis) *RedisBackplane {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	backplane := NewRedisBackplane(client, RedisBackplaneOptions{})
+	t.Cleanup(func() {
+		// 第二次 Close 不能 panic
+		for range 2 {
+			if err := backplane.Close(); err != nil {
+				t.Error(err)
+			}
+		}
+		client.Close()
+	})
+	return backplane
+}
+
+func TestCluster(t *testing.T) {
+	t.Run("local", func(t *testing.T) {
+		backplane := NewLocalBackplane()
+		testCluster(t, backplane, backplane)
+	})
+	t.Run("redis", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		testCluster(t, newRedisBackplane(t, mr), newRedisBackplane(t, mr))
+	})
+}
+
+// testCluster 在兩個節點上各開一個 hub，alice 與 carol 連到 node-a，bob 連到 node-b
+func testCluster(t *testing.T, a, b Backplane) {
+	hubA, hubB := NewHub(), NewHub()
+	hubA.Node, hubA.Backplane = "node-a", a
+	hubB.Node, hubB.Backplane = "node-b", b
+	conns := startMembers(t, hubA, "alice", "carol")
+	for id, conn := range startMembers(t, hubB, "bob") {
+		conns[id] = conn
+	}
+	alice, bob, carol := conns["alice"], conns["bob"], conns["carol"]
+
+	for _, conn := range []*websocket.Conn{alice, bob} {
+		if err := call(t, conn, "join_room", &pb.JoinRoomReq{RoomId: "lobby"}, &pb.JoinRoomResp{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := members(t, carol, "lobby"); !slices.Equal(got, []string{"alice", "bob"}) {
+		t.Errorf("members = %v, want members on both nodes", got)
+	}
+
+	sent := &pb.RoomMessageResp{}
+	if err := call(t, alice, "room_message", &pb.RoomMessageReq{RoomId: "lobby", Data: []byte("hi")}, sent); err != nil || sent.Delivered != 1 {
+		t.Fatalf("room_message: %v %v", sent, err)
+	}
+	if chat := readChat(t, bob, "room_message"); chat.From != "alice" || string(chat.Data) != "hi" {
+		t.Errorf("bob got %v", chat)
+	}
+
+	// 私訊跨節點送達，carol 的下一則訊息就是 bob 的私訊，代表她沒有收到房間訊息
+	if err := call(t, bob, "direct_message", &pb.DirectMessageReq{MemberId: "carol", Data: []byte("psst")}, &pb.DirectMessageResp{}); err != nil {
+		t.Fatal(err)
+	}
+	if chat := readChat(t, carol, "direct_message"); chat.From != "bob" || string(chat.Data) != "psst" {
+		t.Errorf("carol got %v", chat)
+	}
+
+	// bob 斷線後其他節點也看得到
+	bob.Close()
+	waitMembers(t, alice, "lobby", "alice")
+	if err := call(t, alice, "direct_message", &pb.DirectMessageReq{MemberId: "bob"}, &pb.DirectMessageResp{}); err.GetCode() != CodeNotFound {
+		t.Errorf("direct_message to bob after disconnecting: %v", err)
+	}
+}
+
+func TestRedisPresence(t *testing.T) {
+	mr := miniredis.RunT(t)
+	backplane := newRedisBackplane(t, mr)
+	ctx := context.Background()
+	backplane.Subscribe("node-a", func(*pb.Relay) {})
+	backplane.Subscribe("node-b", func(*pb.Relay) {})
+
+	// 換到 node-b 重新連線之後，node-a 晚到的下線不會清掉新的記錄
+	backplane.SetOnline(ctx, "node-a", "alice", true)
+	backplane.SetOnline(ctx, "node-b", "alice", true)
+	backplane.SetOnline(ctx, "node-a", "alice", false)
+	if online, err := backplane.Online(ctx, "alice"); !online || err != nil {
+		t.Errorf("alice should be online on node-b: %v %v", online, err)
+	}
+
+	backplane.SetInRoom(ctx, "node-a", "lobby", "carol", true)
+	backplane.SetInRoom(ctx, "node-b", "lobby", "dave", true)
+	if got, _ := backplane.RoomMembers(ctx, "lobby"); !slices.Equal(got, []string{"carol", "dave"}) {
+		t.Errorf("members = %v", got)
+	}
+
+	// node-b 當掉，心跳過期後它的 member 都不算在線上
+	mr.Del(backplane.nodeKey("node-b"))
+	if online, _ := backplane.Online(ctx, "alice"); online {
+		t.Error("alice's node is gone, alice should be offline")
+	}
+	if got, _ := backplane.RoomMembers(ctx, "lobby"); !slices.Equal(got, []string{"carol"}) {
+		t.Errorf("members after node-b died = %v", got)
+	}
+	if mr.HGet(backplane.roomKey("lobby"), "dave") != "" {
+		t.Error("dave's stale room entry should be cleaned up")
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+const (
+	// 寫入一個 frame 的期限
+	writeWait = 10 * time.Second
+	// 超過 pongWait 沒收到任何資料 (包含 pong) 就當作斷線，pingPeriod 必須比它短
+	pongWait   = 60 * time.Second
+	pingPeriod = 15 * time.Second
+	// 單一 frame 的大小上限，JSON 模式的 payload 是 hex，大小是 protobuf 的兩倍
+	maxMessageSize = 8192
+	// Send 的 buffer，滿了代表 client 跟不上
+	sendBufferSize = 256
+)
+
+// Subprotocol 決定 server 送出的格式：protobuf (預設) 是 binary frame，json 是 IncomingMessage 的 text frame。
+// 收到的訊息兩種格式都接受，依 frame 的類型判斷
+const (
+	SubprotocolProtobuf = "protobuf"
+	SubprotocolJSON     = "json"
+)
+
+type Client struct {
+	ID   string           // 可以放 member id
+	Conn *websocket.Conn  // 實際的 ws connection
+	Send chan *pb.Message // 要送出去的資料，只有 Hub 會寫入與關閉
+	Hub  *Hub             // 反向知道自己屬於哪個 hub
+	JSON bool             // 用 text frame 送出 JSON
+	// Authenticated 代表 ID 是 Hub.Authenticate 驗證過的 member id，不是隨機產生的
+	Authenticated bool
+
+	limiter *rate.Limiter // 見 RateLimit
+
+	// 以下只在 Hub.Run 裡讀寫
+	rooms       map[string]bool // 加入的房間
+	session     *session
+	detached    bool        // Send 已經關閉，等待 resume 或已經被取代
+	expire      *time.Timer // 見 Hub.ResumeWindow
+	resumeToken string      // 連線時要求接續的 session
+	lastSeq     uint64
+}
+
+// IncomingMessage 是 JSON 模式的 frame，對應 pb.Message
+type IncomingMessage struct {
+	Cmd     string     `json:"cmd"`
+	Payload string     `json:"payload"` // payload 是 hex 字串
+	ReqSeq  uint64     `json:"req_seq,omitempty"`
+	Error   *ErrorBody `json:"error,omitempty"`
+	Seq     uint64     `json:"seq,omitempty"`
+	Ack     uint64     `json:"ack,omitempty"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ReadPump 讀端（接前端來的資料）
+func (c *Client) ReadPump() {
+	defer func() {
+		c.Hub.Unregister <- c
+		c.Conn.Close()
+	}()
+	c.Conn.SetReadLimit(maxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.Conn.SetPongHandler(func(string) error {
+		return c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		messageType, data, err := c.Conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				fmt.Println("Error reading from websocket:", err)
+			}
+			return
+		}
+		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		msg, err := decode(messageType, data)
+		if err != nil {
+			fmt.Println("Error decoding from websocket:", err)
+			c.Conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseUnsupportedData, err.Error()), time.Now().Add(writeWait))
+			return
+		}
+
+		if msg.Ack > 0 {
+			c.Hub.Ack(c, msg.Ack)
+		}
+		if msg.Cmd == "ack" {
+			continue
+		}
+		c.Hub.Direct <- Envelope{To: c, Message: c.Hub.Commands.Dispatch(c, msg)}
+	}
+}
+
+// WritePump 寫端（推資料出去），每個連線只有這個 goroutine 會寫入
+func (c *Client) WritePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.Conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+
+			messageType, data, err := c.encode(message)
+			if err != nil {
+				fmt.Println("Error encoding message:", err)
+				continue
+			}
+			if err := c.Conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) encode(msg *pb.Message) (int, []byte, error) {
+	if c.JSON {
+		out := IncomingMessage{Cmd: msg.Cmd, Payload: hex.EncodeToString(msg.Payload), ReqSeq: msg.ReqSeq, Seq: msg.Seq}
+		if msg.Error != nil {
+			out.Error = &ErrorBody{Code: msg.Error.Code, Message: msg.Error.Message}
+		}
+		data, err := json.Marshal(out)
+		return websocket.TextMessage, data, err
+	}
+	data, err := proto.Marshal(msg)
+	return websocket.BinaryMessage, data, err
+}
+
+func decode(messageType int, data []byte) (*pb.Message, error) {
+	if messageType == websocket.BinaryMessage {
+		msg := &pb.Message{}
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+
+	var input IncomingMessage
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+	payload, err := hex.DecodeString(input.Payload)
+	if err != nil {
+		return nil, errors.New("payload is not a hex string")
+	}
+	msg := &pb.Message{Cmd: input.Cmd, Payload: payload, ReqSeq: input.ReqSeq, Ack: input.Ack}
+	if input.Error != nil {
+		msg.Error = &pb.Error{Code: input.Error.Code, Message: input.Error.Message}
+	}
+	return msg, nil
+}
+
+// 用來升級 HTTP -> WebSocket
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true // 開放跨域
+	},
+	Subprotocols: []string{SubprotocolProtobuf, SubprotocolJSON},
+}
+
+// ServeWs 升級連線並註冊到 hub。hub.Authenticate 有設定時以它回傳的 member id 當作 client ID，
+// 驗證失敗回 401；沒有設定時每個連線使用隨機的 ID。
+// 重新連線時可以帶上 resume_token 與 last_seq (收到的最後一個 seq) 接續之前的 session，見 Hub.register
+func ServeWs(hub *Hub, c *gin.Context) {
+	var lastSeq uint64
+	if s := c.Query("last_seq"); s != "" {
+		var err error
+		if lastSeq, err = strconv.ParseUint(s, 10, 64); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "last_seq must be an unsigned integer"})
+			return
+		}
+	}
+
+	id, authenticated := newClientID(), false
+	if hub.Authenticate != nil {
+		memberID, err := hub.Authenticate(c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		id, authenticated = memberID, true
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	client := &Client{
+		ID:   id,
+		Conn: conn,
+		Send: make(chan *pb.Message, sendBufferSize),
+		Hub:  hub,
+		JSON: conn.Subprotocol() == SubprotocolJSON,
+		// 匿名連線的 ID 是隨機產生的
+		Authenticated: authenticated,
+		resumeToken:   c.Query("resume_token"),
+		lastSeq:       lastSeq,
+	}
+	// 等註冊完成才啟動 pump，resume 時 client.ID 可能會改變
+	hub.do(func() { hub.register(client) })
+
+	go client.WritePump()
+	go client.ReadPump()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+// registerCommands 註冊所有指令。私訊的收件人是 member id，匿名連線的 ID 是隨機的，所以只開放給 member
+func registerCommands(r *CommandRouter) {
+	Handle(r, "ping", ping)
+	Handle(r, "join_room", joinRoom)
+	Handle(r, "leave_room", leaveRoom)
+	Handle(r, "room_members", roomMembers)
+	Handle(r, "room_message", roomMessage)
+	Handle(r, "direct_message", directMessage, RequireMember)
+}
+
+func ping(_ *Request, req *pb.Ping) (*pb.Pong, error) {
+	return &pb.Pong{ClientTime: req.ClientTime, ServerTime: time.Now().UnixMilli()}, nil
+}
+
+func joinRoom(req *Request, payload *pb.JoinRoomReq) (*pb.JoinRoomResp, error) {
+	if payload.RoomId == "" {
+		return nil, Errorf(CodeInvalidArgument, "room_id is required")
+	}
+	if !req.Client.Hub.JoinRoom(req.Client, payload.RoomId) {
+		return &pb.JoinRoomResp{Status: "already_joined", Message: "already in room " + payload.RoomId}, nil
+	}
+	return &pb.JoinRoomResp{Status: "ok", Message: "joined room " + payload.RoomId}, nil
+}
+
+func leaveRoom(req *Request, payload *pb.LeaveRoomReq) (*pb.LeaveRoomResp, error) {
+	if payload.RoomId == "" {
+		return nil, Errorf(CodeInvalidArgument, "room_id is required")
+	}
+	if !req.Client.Hub.LeaveRoom(req.Client, payload.RoomId) {
+		return &pb.LeaveRoomResp{Status: "not_joined"}, nil
+	}
+	return &pb.LeaveRoomResp{Status: "ok"}, nil
+}
+
+func roomMembers(req *Request, payload *pb.RoomMembersReq) (*pb.RoomMembersResp, error) {
+	if payload.RoomId == "" {
+		return nil, Errorf(CodeInvalidArgument, "room_id is required")
+	}
+	members, err := req.Client.Hub.RoomMembers(payload.RoomId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RoomMembersResp{MemberIds: members}, nil
+}
+
+// roomMessage 只有房間成員可以發言
+func roomMessage(req *Request, payload *pb.RoomMessageReq) (*pb.RoomMessageResp, error) {
+	hub := req.Client.Hub
+	if !hub.InRoom(req.Client, payload.RoomId) {
+		return nil, Errorf(CodePermissionDenied, "not in room %q", payload.RoomId)
+	}
+	msg, err := chatMessage("room_message", &pb.ChatMessage{RoomId: payload.RoomId, From: req.Client.ID, Data: payload.Data})
+	if err != nil {
+		return nil, err
+	}
+	delivered, err := hub.SendToRoom(payload.RoomId, msg, req.Client)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RoomMessageResp{Delivered: int32(delivered)}, nil
+}
+
+func directMessage(req *Request, payload *pb.DirectMessageReq) (*pb.DirectMessageResp, error) {
+	msg, err := chatMessage("direct_message", &pb.ChatMessage{From: req.Client.ID, Data: payload.Data})
+	if err != nil {
+		return nil, err
+	}
+	delivered, err := req.Client.Hub.SendToMember(payload.MemberId, msg)
+	if err != nil {
+		return nil, err
+	}
+	if !delivered {
+		return nil, Errorf(CodeNotFound, "member %q is not reachable", payload.MemberId)
+	}
+	return &pb.DirectMessageResp{}, nil
+}
+
+func chatMessage(cmd string, chat *pb.ChatMessage) (*pb.Message, error) {
+	payload, err := proto.Marshal(chat)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Message{Cmd: cmd, Payload: payload}, nil
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+// SlowConsumerPolicy 決定 client 的 Send 滿了 (跟不上) 時怎麼處理，hub 本身不會等待任何 client
+type SlowConsumerPolicy int
+
+const (
+	// DisconnectSlowConsumer 直接斷線，client 重新連線後可以 resume 或重新同步
+	DisconnectSlowConsumer SlowConsumerPolicy = iota
+	// DropMessages 丟掉送不出去的訊息，連線保持
+	DropMessages
+)
+
+const (
+	// 每次呼叫 backplane 的期限
+	backplaneTimeout = 5 * time.Second
+	// 還沒寫到 backplane 的 presence 更新，滿了 Run 會等待
+	presenceBufferSize = 1024
+)
+
+// Hub 管理這個節點上的連線，Clients、Rooms 與每個 client 的 Send 只在 Run 的 goroutine 裡讀寫，
+// 其他 goroutine 一律透過 channel 交給 Run 處理，所以不需要 lock。
+// 房間訊息與私訊透過 Backplane 送到其他節點，Broadcast 只送給這個節點的 client
+type Hub struct {
+	Clients    map[string]*Client
+	Rooms      map[string]map[string]*Client // room id -> client id -> client，只有這個節點上的 client
+	Broadcast  chan *pb.Message
+	Direct     chan Envelope
+	Register   chan *Client
+	Unregister chan *Client
+
+	// Authenticate 從升級前的 HTTP 請求取得 member id，nil 時每個連線使用隨機的 ID
+	Authenticate func(r *http.Request) (string, error)
+	// Commands 處理 client 送來的指令，回應送回給同一個 client
+	Commands     *CommandRouter
+	SlowConsumer SlowConsumerPolicy
+	// Backplane 預設是只有這個節點的 LocalBackplane，Node 是這個節點在 backplane 上的 ID，預設隨機產生。
+	// 兩者都要在 Run 之前設定
+	Backplane Backplane
+	Node      string
+	// ResumeWindow 是斷線後保留 session 的時間，期間 client 仍在房間裡，送給它的訊息放進 replay buffer 等它 resume。
+	// 0 代表斷線就結束 session，只有 server 還沒發現舊連線斷掉時才能 resume。
+	// session 只存在這個節點上，連到其他節點時只能重新同步
+	ResumeWindow time.Duration
+
+	sessions map[string]*Client // resume token -> 目前持有 session 的 client
+	actions  chan func()
+	presence chan func(ctx context.Context) error // 依序寫到 Backplane 的 presence 更新
+}
+
+// Envelope 是送給單一 client 的訊息
+type Envelope struct {
+	To      *Client
+	Message *pb.Message
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		Clients:    make(map[string]*Client),
+		Rooms:      make(map[string]map[string]*Client),
+		Broadcast:  make(chan *pb.Message),
+		Direct:     make(chan Envelope),
+		Register:   make(chan *Client),
+		Unregister: make(chan *Client),
+		Commands:   NewCommandRouter(),
+		Backplane:  NewLocalBackplane(),
+		Node:       newClientID(),
+		sessions:   make(map[string]*Client),
+		actions:    make(chan func()),
+		presence:   make(chan func(ctx context.Context) error, presenceBufferSize),
+	}
+}
+
+func (h *Hub) Run() {
+	h.Backplane.Subscribe(h.Node, h.receive)
+	go h.syncPresence()
+	for {
+		select {
+		case client := <-h.Register:
+			h.register(client)
+		case client := <-h.Unregister:
+			// 被新連線取代或已經被踢掉的 client 不在 Clients 裡 (或已經換成別的 client)
+			if h.registered(client) && !client.detached {
+				h.disconnect(client)
+			}
+		case message := <-h.Broadcast:
+			for _, client := range h.Clients {
+				h.deliver(client, message)
+			}
+		case envelope := <-h.Direct:
+			if h.registered(envelope.To) {
+				h.deliver(envelope.To, envelope.Message)
+			}
+		case action := <-h.actions:
+			action()
+		}
+	}
+}
+
+// do 在 Run 的 goroutine 裡執行 f 並等它完成，f 裡可以直接讀寫 Clients 與 Rooms
+func (h *Hub) do(f func()) {
+	done := make(chan struct{})
+	h.actions <- func() {
+		f()
+		close(done)
+	}
+	<-done
+}
+
+// register 註冊新的連線並送出 welcome。client 帶著 resume token 時，如果 session 還在而且缺少的訊息都在
+// buffer 裡，新的連線接手 session (房間、序號與 buffer) 並補送 lastSeq 之後的訊息；否則開始新的 session 並要求重新同步。
+// 匿名連線 resume 時會換成原本的 ID
+func (h *Hub) register(client *Client) {
+	resume := ""
+	if client.resumeToken != "" {
+		resume = ResumeResync
+		old := h.sessions[client.resumeToken]
+		if old != nil && old.Authenticated == client.Authenticated && (old.ID == client.ID || !client.Authenticated) &&
+			old.session.covers(client.lastSeq) {
+			resume = ResumeResumed
+			h.takeOver(old, client)
+		}
+	}
+
+	if resume != ResumeResumed {
+		// 同一個 member 重複連線時踢掉舊的連線
+		if old, ok := h.Clients[client.ID]; ok {
+			h.remove(old)
+		}
+		client.session = newSession()
+		h.Clients[client.ID] = client
+		h.sessions[client.session.token] = client
+		h.setOnline(client, true)
+	}
+
+	h.send(client, welcome(client, resume))
+	if resume == ResumeResumed {
+		client.session.ack(client.lastSeq)
+		for _, message := range client.session.buffer {
+			h.send(client, message)
+		}
+	}
+}
+
+// takeOver 把 old 的 session 與房間交給 client，old 的連線如果還在就關閉
+func (h *Hub) takeOver(old, client *Client) {
+	client.ID = old.ID
+	client.session = old.session
+	// 複製一份，舊連線還在處理的指令不會影響新的連線
+	client.rooms = maps.Clone(old.rooms)
+	for room := range client.rooms {
+		h.Rooms[room][client.ID] = client
+	}
+	h.Clients[client.ID] = client
+	h.sessions[client.session.token] = client
+
+	if old.expire != nil {
+		old.expire.Stop()
+	}
+	if !old.detached {
+		old.detached = true
+		close(old.Send)
+	}
+}
+
+// disconnect 在連線斷掉或跟不上時呼叫，ResumeWindow 內保留 session，之後才移除
+func (h *Hub) disconnect(client *Client) {
+	if h.ResumeWindow <= 0 {
+		h.remove(client)
+		return
+	}
+	client.detached = true
+	close(client.Send)
+	client.expire = time.AfterFunc(h.ResumeWindow, func() {
+		h.do(func() {
+			if h.registered(client) {
+				h.remove(client)
+			}
+		})
+	})
+}
+
+// Ack 把 client 已經收到的訊息從 replay buffer 移除
+func (h *Hub) Ack(client *Client, seq uint64) {
+	h.do(func() {
+		if h.registered(client) {
+			client.session.ack(seq)
+		}
+	})
+}
+
+// receive 把其他節點送來的訊息交給這個節點上的 client
+func (h *Hub) receive(relay *pb.Relay) {
+	if relay.Origin == h.Node {
+		return
+	}
+	h.do(func() {
+		switch {
+		case relay.RoomId != "":
+			for id, client := range h.Rooms[relay.RoomId] {
+				if id != relay.Except {
+					h.deliver(client, relay.Message)
+				}
+			}
+		case relay.MemberId != "":
+			if client, ok := h.Clients[relay.MemberId]; ok {
+				h.deliver(client, relay.Message)
+			}
+		}
+	})
+}
+
+// syncPresence 依序把 presence 更新寫到 Backplane，不讓 Run 等待網路
+func (h *Hub) syncPresence() {
+	for update := range h.presence {
+		ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+		if err := update(ctx); err != nil {
+			fmt.Println("presence update failed:", err)
+		}
+		cancel()
+	}
+}
+
+// flushPresence 等到之前的 presence 更新都寫到 Backplane
+func (h *Hub) flushPresence() {
+	done := make(chan struct{})
+	h.presence <- func(context.Context) error {
+		close(done)
+		return nil
+	}
+	<-done
+}
+
+func (h *Hub) setOnline(client *Client, online bool) {
+	h.presence <- func(ctx context.Context) error {
+		return h.Backplane.SetOnline(ctx, h.Node, client.ID, online)
+	}
+}
+
+func (h *Hub) setInRoom(client *Client, room string, in bool) {
+	h.presence <- func(ctx context.Context) error {
+		return h.Backplane.SetInRoom(ctx, h.Node, room, client.ID, in)
+	}
+}
+
+// JoinRoom 讓 client 加入房間，已經在房間裡或已經斷線時回傳 false。
+// 回傳時 Backplane 上的 presence 已經更新
+func (h *Hub) JoinRoom(client *Client, room string) (joined bool) {
+	h.do(func() {
+		if !h.registered(client) || client.rooms[room] {
+			return
+		}
+		if h.Rooms[room] == nil {
+			h.Rooms[room] = make(map[string]*Client)
+		}
+		h.Rooms[room][client.ID] = client
+		if client.rooms == nil {
+			client.rooms = make(map[string]bool)
+		}
+		client.rooms[room] = true
+		h.setInRoom(client, room, true)
+		joined = true
+	})
+	h.flushPresence()
+	return joined
+}
+
+// LeaveRoom 讓 client 離開房間，不在房間裡或已經被新連線取代時回傳 false
+func (h *Hub) LeaveRoom(client *Client, room string) (left bool) {
+	h.do(func() {
+		if !h.registered(client) || !client.rooms[room] {
+			return
+		}
+		h.leave(client, room)
+		left = true
+	})
+	h.flushPresence()
+	return left
+}
+
+// InRoom 回傳 client 是否在房間裡，已經被新連線取代的 client 不在任何房間裡
+func (h *Hub) InRoom(client *Client, room string) (in bool) {
+	h.do(func() { in = h.registered(client) && client.rooms[room] })
+	return in
+}
+
+// RoomMembers 回傳房間內所有節點上的成員 ID，依 ID 排序
+func (h *Hub) RoomMembers(room string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	return h.Backplane.RoomMembers(ctx, room)
+}
+
+// SendToRoom 送給房間內除了 except 以外的成員，回傳送出的人數：
+// 這個節點上的成員是實際放進 Send 的人數，其他節點上的成員依 presence 計算。
+// 其他節點上沒有成員時不會 publish
+func (h *Hub) SendToRoom(room string, message *pb.Message, except *Client) (int, error) {
+	delivered := 0
+	local := make(map[string]bool)
+	h.do(func() {
+		for id, client := range h.Rooms[room] {
+			local[id] = true
+			if client != except && h.deliver(client, message) {
+				delivered++
+			}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	members, err := h.Backplane.RoomMembers(ctx, room)
+	if err != nil {
+		return delivered, err
+	}
+	relay := &pb.Relay{Origin: h.Node, RoomId: room, Message: message}
+	if except != nil {
+		relay.Except = except.ID
+	}
+	remote := 0
+	for _, id := range members {
+		if !local[id] && id != relay.Except {
+			remote++
+		}
+	}
+	if remote == 0 {
+		return delivered, nil
+	}
+	if err := h.Backplane.Publish(ctx, relay); err != nil {
+		return delivered, err
+	}
+	return delivered + remote, nil
+}
+
+// SendToMember 送給指定 ID 的 client，client 在其他節點上時透過 Backplane 轉送。
+// 不在線上或訊息被丟掉時回傳 false
+func (h *Hub) SendToMember(id string, message *pb.Message) (bool, error) {
+	var found, delivered bool
+	h.do(func() {
+		if client, ok := h.Clients[id]; ok {
+			found = true
+			delivered = h.deliver(client, message)
+		}
+	})
+	if found {
+		return delivered, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	online, err := h.Backplane.Online(ctx, id)
+	if err != nil || !online {
+		return false, err
+	}
+	if err := h.Backplane.Publish(ctx, &pb.Relay{Origin: h.Node, MemberId: id, Message: message}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *Hub) registered(client *Client) bool {
+	return h.Clients[client.ID] == client
+}
+
+// deliver 給訊息加上 client session 的序號並放進 replay buffer 後送出。
+// client 已經斷線、等待 resume 時只放進 buffer，並回傳 true
+func (h *Hub) deliver(client *Client, message *pb.Message) bool {
+	message = client.session.push(message)
+	if client.detached {
+		return true
+	}
+	return h.send(client, message)
+}
+
+// send 不會等待，Send 滿了代表 client 跟不上，依 SlowConsumer 斷線或丟掉訊息，避免拖慢其他 client。
+// 丟掉的訊息還在 replay buffer 裡，client 發現序號跳號時可以重新連線 resume
+func (h *Hub) send(client *Client, message *pb.Message) bool {
+	select {
+	case client.Send <- message:
+		return true
+	default:
+	}
+	if h.SlowConsumer == DropMessages {
+		fmt.Println("client is too slow, dropping message:", client.ID, message.Cmd)
+		return false
+	}
+	fmt.Println("client is too slow, disconnecting:", client.ID)
+	h.disconnect(client)
+	return false
+}
+
+// remove 結束 client 的 session。關閉 Send 後 WritePump 會送出 close frame 並關閉連線，ReadPump 接著結束
+func (h *Hub) remove(client *Client) {
+	for room := range client.rooms {
+		h.leave(client, room)
+	}
+	delete(h.Clients, client.ID)
+	delete(h.sessions, client.session.token)
+	h.setOnline(client, false)
+	if client.expire != nil {
+		client.expire.Stop()
+	}
+	if !client.detached {
+		client.detached = true
+		close(client.Send)
+	}
+}
+
+func (h *Hub) leave(client *Client, room string) {
+	delete(client.rooms, room)
+	delete(h.Rooms[room], client.ID)
+	h.setInRoom(client, room, false)
+	if len(h.Rooms[room]) == 0 {
+		delete(h.Rooms, room)
+	}
+}
+
+func welcome(client *Client, resume string) *pb.Message {
+	payload, _ := proto.Marshal(&pb.Welcome{ClientId: client.ID, ResumeToken: client.session.token, Resume: resume})
+	return &pb.Message{Cmd: "welcome", Payload: payload}
+}
+
+// newClientID 產生匿名連線的 ID
+func newClientID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+func startServer(t *testing.T, hub *Hub) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	registerCommands(hub.Commands)
+	go hub.Run()
+	srv := httptest.NewServer(newRouter(hub))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dial(t *testing.T, srv *httptest.Server, subprotocol string, header http.Header) *websocket.Conn {
+	t.Helper()
+	return dialURL(t, srv, "/ws", subprotocol, header)
+}
+
+func dialURL(t *testing.T, srv *httptest.Server, path, subprotocol string, header http.Header) *websocket.Conn {
+	t.Helper()
+	dialer := *websocket.DefaultDialer
+	if subprotocol != "" {
+		dialer.Subprotocols = []string{subprotocol}
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+path, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// read 讀取一則訊息，binary frame 用 protobuf 解析，text frame 用 JSON 解析
+func read(t *testing.T, conn *websocket.Conn) (int, *pb.Message) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := decode(messageType, data)
+	if err != nil {
+		t.Fatalf("%v: %q", err, data)
+	}
+	return messageType, msg
+}
+
+func readWelcome(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+	return readSession(t, conn).ClientId
+}
+
+func readSession(t *testing.T, conn *websocket.Conn) *pb.Welcome {
+	t.Helper()
+	_, msg := read(t, conn)
+	welcome := &pb.Welcome{}
+	if err := proto.Unmarshal(msg.Payload, welcome); msg.Cmd != "welcome" || msg.Seq != 0 || err != nil {
+		t.Fatalf("first message = %v, want welcome", msg)
+	}
+	return welcome
+}
+
+func marshal(t *testing.T, msg proto.Message) []byte {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg *pb.Message) {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.BinaryMessage, marshal(t, msg)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProtobufFrames(t *testing.T) {
+	srv := startServer(t, NewHub())
+	a := dial(t, srv, "", nil)
+	b := dial(t, srv, SubprotocolProtobuf, nil)
+
+	idA, idB := readWelcome(t, a), readWelcome(t, b)
+	if idA == "" || idA == idB {
+		t.Fatalf("client ids %q and %q should be unique", idA, idB)
+	}
+
+	send(t, a, &pb.Message{Cmd: "ping", Payload: marshal(t, &pb.Ping{ClientTime: 42}), ReqSeq: 7})
+	messageType, msg := read(t, a)
+	pong := &pb.Pong{}
+	if err := proto.Unmarshal(msg.Payload, pong); err != nil || messageType != websocket.BinaryMessage {
+		t.Fatalf("response = %d %v", messageType, msg)
+	}
+	if msg.Cmd != "ping" || msg.ReqSeq != 7 || msg.Error != nil || pong.ClientTime != 42 || pong.ServerTime == 0 {
+		t.Errorf("response = %v, pong = %v", msg, pong)
+	}
+}
+
+func TestJSONFallback(t *testing.T) {
+	srv := startServer(t, NewHub())
+	conn := dial(t, srv, SubprotocolJSON, nil)
+	if conn.Subprotocol() != SubprotocolJSON {
+		t.Fatalf("subprotocol = %q", conn.Subprotocol())
+	}
+	readWelcome(t, conn)
+
+	ping := IncomingMessage{Cmd: "ping", Payload: hex.EncodeToString(marshal(t, &pb.Ping{ClientTime: 42})), ReqSeq: 3}
+	if got := readJSON(t, conn, ping); got.Cmd != "ping" || got.ReqSeq != 3 || got.Error != nil || got.Payload == "" {
+		t.Errorf("response = %+v", got)
+	}
+
+	// 不認得的指令回傳 error，連線不會中斷
+	unknown := IncomingMessage{Cmd: "start_game", ReqSeq: 4}
+	got := readJSON(t, conn, unknown)
+	if got.ReqSeq != 4 || got.Error == nil || got.Error.Code != CodeUnknownCommand || got.Payload != "" {
+		t.Errorf("response = %+v", got)
+	}
+}
+
+// readJSON 送出 msg 並讀取一個 JSON text frame
+func readJSON(t *testing.T, conn *websocket.Conn, msg IncomingMessage) IncomingMessage {
+	t.Helper()
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got IncomingMessage
+	if err := json.Unmarshal(data, &got); messageType != websocket.TextMessage || err != nil {
+		t.Fatalf("response = %d %s, want a JSON text frame", messageType, data)
+	}
+	return got
+}
+
+func TestInvalidFrameClosesConnection(t *testing.T) {
+	srv := startServer(t, NewHub())
+	conn := dial(t, srv, SubprotocolJSON, nil)
+	readWelcome(t, conn)
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"cmd":"x","payload":"not hex"}`))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseUnsupportedData) {
+		t.Errorf("err = %v, want close 1003", err)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	hub := NewHub()
+	srv := startServer(t, hub)
+	conns := []*websocket.Conn{dial(t, srv, "", nil), dial(t, srv, SubprotocolJSON, nil)}
+	for _, conn := range conns {
+		readWelcome(t, conn)
+	}
+
+	hub.Broadcast <- &pb.Message{Cmd: "notice", Payload: []byte("hi")}
+	for _, conn := range conns {
+		if _, msg := read(t, conn); msg.Cmd != "notice" || string(msg.Payload) != "hi" {
+			t.Errorf("got %v", msg)
+		}
+	}
+}
+
+func TestAuthenticatedMember(t *testing.T) {
+	hub := NewHub()
+	hub.Authenticate = headerAuthenticator("X-Member-Id")
+	srv := startServer(t, hub)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dial without member id: %v %v", resp, err)
+	}
+
+	member := http.Header{"X-Member-Id": {"member-42"}}
+	first := dial(t, srv, "", member)
+	if id := readWelcome(t, first); id != "member-42" {
+		t.Fatalf("client id = %q, want the member id", id)
+	}
+
+	// 同一個 member 再連一次，舊的連線會被關閉
+	second := dial(t, srv, "", member)
+	readWelcome(t, second)
+	first.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := first.ReadMessage(); err == nil {
+		t.Error("the first connection should be closed")
+	}
+
+	send(t, second, &pb.Message{Cmd: "ping", ReqSeq: 1})
+	if _, msg := read(t, second); msg.Cmd != "ping" || msg.Error != nil {
+		t.Errorf("got %v", msg)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+)
+
+// Logging 記錄每個指令的 client、req_seq、耗時與錯誤
+func Logging(logger *log.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (proto.Message, error) {
+			start := time.Now()
+			resp, err := next(req)
+			if err != nil {
+				logger.Printf("cmd=%s client=%s req_seq=%d duration=%s error=%v", req.Cmd, req.Client.ID, req.ReqSeq, time.Since(start), err)
+			} else {
+				logger.Printf("cmd=%s client=%s req_seq=%d duration=%s", req.Cmd, req.Client.ID, req.ReqSeq, time.Since(start))
+			}
+			return resp, err
+		}
+	}
+}
+
+// RateLimit 限制每個連線每秒最多 limit 個指令，可以瞬間送出 burst 個
+func RateLimit(limit rate.Limit, burst int) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (proto.Message, error) {
+			// 同一個連線的指令都在它的 ReadPump 裡依序處理，limiter 不會被同時存取
+			if req.Client.limiter == nil {
+				req.Client.limiter = rate.NewLimiter(limit, burst)
+			}
+			if !req.Client.limiter.Allow() {
+				return nil, Errorf(CodeRateLimited, "too many commands, slow down")
+			}
+			return next(req)
+		}
+	}
+}
+
+// RequireMember 只允許經過 Hub.Authenticate 驗證的連線，匿名連線回 UNAUTHENTICATED
+func RequireMember(next Handler) Handler {
+	return func(req *Request) (proto.Message, error) {
+		if !req.Client.Authenticated {
+			return nil, Errorf(CodeUnauthenticated, "%s requires a signed-in member", req.Cmd)
+		}
+		return next(req)
+	}
+}
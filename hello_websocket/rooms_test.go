@@ -0,0 +1,176 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+// startMembers 啟動 server 並以 member id 連線，回傳時都已經收到 welcome
+func startMembers(t *testing.T, hub *Hub, ids ...string) map[string]*websocket.Conn {
+	t.Helper()
+	hub.Authenticate = headerAuthenticator("X-Member-Id")
+	srv := startServer(t, hub)
+	conns := map[string]*websocket.Conn{}
+	for _, id := range ids {
+		conns[id] = dial(t, srv, "", http.Header{"X-Member-Id": {id}})
+		readWelcome(t, conns[id])
+	}
+	return conns
+}
+
+// call 送出指令並讀取下一則訊息，它必須是這個指令的回應
+func call(t *testing.T, conn *websocket.Conn, cmd string, req, resp proto.Message) *pb.Error {
+	t.Helper()
+	send(t, conn, &pb.Message{Cmd: cmd, Payload: marshal(t, req), ReqSeq: 99})
+	_, msg := read(t, conn)
+	if msg.Cmd != cmd || msg.ReqSeq != 99 {
+		t.Fatalf("%s: got %v, want its response", cmd, msg)
+	}
+	if msg.Error == nil {
+		if err := proto.Unmarshal(msg.Payload, resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return msg.Error
+}
+
+func readChat(t *testing.T, conn *websocket.Conn, cmd string) *pb.ChatMessage {
+	t.Helper()
+	_, msg := read(t, conn)
+	chat := &pb.ChatMessage{}
+	if err := proto.Unmarshal(msg.Payload, chat); msg.Cmd != cmd || msg.ReqSeq != 0 || err != nil {
+		t.Fatalf("got %v, want a pushed %s", msg, cmd)
+	}
+	return chat
+}
+
+func members(t *testing.T, conn *websocket.Conn, room string) []string {
+	t.Helper()
+	resp := &pb.RoomMembersResp{}
+	if err := call(t, conn, "room_members", &pb.RoomMembersReq{RoomId: room}, resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.MemberIds
+}
+
+// waitMembers 等到房間成員變成 want，斷線是非同步處理的
+func waitMembers(t *testing.T, conn *websocket.Conn, room string, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	got := members(t, conn, room)
+	for !slices.Equal(got, want) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		got = members(t, conn, room)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("members of %s = %v, want %v", room, got, want)
+	}
+}
+
+func TestRooms(t *testing.T) {
+	conns := startMembers(t, NewHub(), "alice", "bob", "carol")
+	alice, bob, carol := conns["alice"], conns["bob"], conns["carol"]
+
+	for _, conn := range []*websocket.Conn{alice, bob} {
+		joined := &pb.JoinRoomResp{}
+		if err := call(t, conn, "join_room", &pb.JoinRoomReq{RoomId: "lobby"}, joined); err != nil || joined.Status != "ok" {
+			t.Fatalf("join_room: %v %v", joined, err)
+		}
+	}
+	again := &pb.JoinRoomResp{}
+	if call(t, alice, "join_room", &pb.JoinRoomReq{RoomId: "lobby"}, again); again.Status != "already_joined" {
+		t.Errorf("joining twice: %v", again)
+	}
+	if got := members(t, carol, "lobby"); !slices.Equal(got, []string{"alice", "bob"}) {
+		t.Errorf("members = %v", got)
+	}
+
+	// 房間訊息只送給其他成員
+	sent := &pb.RoomMessageResp{}
+	if err := call(t, alice, "room_message", &pb.RoomMessageReq{RoomId: "lobby", Data: []byte("hi")}, sent); err != nil || sent.Delivered != 1 {
+		t.Fatalf("room_message: %v %v", sent, err)
+	}
+	if chat := readChat(t, bob, "room_message"); chat.RoomId != "lobby" || chat.From != "alice" || string(chat.Data) != "hi" {
+		t.Errorf("bob got %v", chat)
+	}
+	if err := call(t, carol, "room_message", &pb.RoomMessageReq{RoomId: "lobby"}, &pb.RoomMessageResp{}); err.GetCode() != CodePermissionDenied {
+		t.Errorf("carol is not in the room: %v", err)
+	}
+
+	// 私訊；carol 的下一則訊息就是私訊，代表她沒有收到上面的房間訊息
+	if err := call(t, bob, "direct_message", &pb.DirectMessageReq{MemberId: "carol", Data: []byte("psst")}, &pb.DirectMessageResp{}); err != nil {
+		t.Fatal(err)
+	}
+	if chat := readChat(t, carol, "direct_message"); chat.From != "bob" || chat.RoomId != "" || string(chat.Data) != "psst" {
+		t.Errorf("carol got %v", chat)
+	}
+	if err := call(t, bob, "direct_message", &pb.DirectMessageReq{MemberId: "dave"}, &pb.DirectMessageResp{}); err.GetCode() != CodeNotFound {
+		t.Errorf("direct_message to an offline member: %v", err)
+	}
+
+	left := &pb.LeaveRoomResp{}
+	if call(t, bob, "leave_room", &pb.LeaveRoomReq{RoomId: "lobby"}, left); left.Status != "ok" {
+		t.Errorf("leave_room: %v", left)
+	}
+	if call(t, bob, "leave_room", &pb.LeaveRoomReq{RoomId: "lobby"}, left); left.Status != "not_joined" {
+		t.Errorf("leaving twice: %v", left)
+	}
+
+	// 斷線的成員會自動離開所有房間
+	alice.Close()
+	waitMembers(t, carol, "lobby")
+}
+
+func TestDirectMessageRequiresMember(t *testing.T) {
+	srv := startServer(t, NewHub())
+	conn := dial(t, srv, "", nil)
+	readWelcome(t, conn)
+	if err := call(t, conn, "direct_message", &pb.DirectMessageReq{MemberId: "alice"}, &pb.DirectMessageResp{}); err.GetCode() != CodeUnauthenticated {
+		t.Errorf("anonymous direct_message: %v", err)
+	}
+}
+
+func TestSlowConsumerPolicy(t *testing.T) {
+	tests := []struct {
+		policy        SlowConsumerPolicy
+		wantConnected bool
+	}{
+		{DisconnectSlowConsumer, false},
+		{DropMessages, true},
+	}
+	for _, tt := range tests {
+		hub := NewHub()
+		hub.SlowConsumer = tt.policy
+		go hub.Run()
+
+		// Send 只有一格，welcome 就把它佔滿了，沒有人讀取
+		slow := &Client{ID: "slow", Send: make(chan *pb.Message, 1), Hub: hub}
+		fast := &Client{ID: "fast", Send: make(chan *pb.Message, 8), Hub: hub}
+		hub.Register <- slow
+		hub.Register <- fast
+		hub.JoinRoom(slow, "lobby")
+		hub.JoinRoom(fast, "lobby")
+
+		if delivered, err := hub.SendToRoom("lobby", &pb.Message{Cmd: "notice"}, nil); delivered != 1 || err != nil {
+			t.Errorf("policy %d: delivered to %d clients (%v), want only the fast one", tt.policy, delivered, err)
+		}
+		if delivered, err := hub.SendToMember("fast", &pb.Message{Cmd: "notice"}); !delivered || err != nil {
+			t.Errorf("policy %d: the fast client should not be affected", tt.policy)
+		}
+
+		<-slow.Send // 讀掉 welcome
+		if tt.wantConnected {
+			if !hub.InRoom(slow, "lobby") || len(slow.Send) != 0 {
+				t.Errorf("policy %d: the slow client should stay connected with the message dropped", tt.policy)
+			}
+		} else if _, open := <-slow.Send; open || hub.InRoom(slow, "lobby") {
+			t.Errorf("policy %d: the slow client should be disconnected", tt.policy)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+// 錯誤回應的 code
+const (
+	CodeUnknownCommand   = "UNKNOWN_COMMAND"
+	CodeInvalidPayload   = "INVALID_PAYLOAD"
+	CodeInvalidArgument  = "INVALID_ARGUMENT"
+	CodeNotFound         = "NOT_FOUND"
+	CodeUnauthenticated  = "UNAUTHENTICATED"
+	CodePermissionDenied = "PERMISSION_DENIED"
+	CodeRateLimited      = "RATE_LIMITED"
+	CodeInternal         = "INTERNAL"
+)
+
+// CommandError 是 handler 或 middleware 回傳給 client 的錯誤，其他錯誤一律回 INTERNAL 不透露細節
+type CommandError struct {
+	Code    string
+	Message string
+}
+
+func (e *CommandError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func Errorf(code, format string, args ...interface{}) *CommandError {
+	return &CommandError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Request 是一個已經解析好 payload 的指令
+type Request struct {
+	Client  *Client
+	Cmd     string
+	ReqSeq  uint64
+	Payload proto.Message
+}
+
+// Handler 處理指令並回傳回應的內容
+type Handler func(req *Request) (proto.Message, error)
+
+// Middleware 包裝 Handler，例如驗證、限流、記錄
+type Middleware func(next Handler) Handler
+
+type command struct {
+	newRequest func() proto.Message
+	handler    Handler
+}
+
+// CommandRouter 依 pb.Message 的 cmd 找到對應的 handler。
+// 註冊在啟動時完成，之後只會讀取，所以不需要 lock
+type CommandRouter struct {
+	commands   map[string]command
+	middleware []Middleware
+}
+
+func NewCommandRouter() *CommandRouter {
+	return &CommandRouter{commands: map[string]command{}}
+}
+
+// Use 加上套用到所有指令的 middleware，先加的在外層。
+// middleware 在 Handle 時就包好，所以必須在註冊任何指令之前呼叫，否則 panic
+func (r *CommandRouter) Use(middleware ...Middleware) {
+	if len(r.commands) > 0 {
+		panic("CommandRouter.Use called after commands were registered")
+	}
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Handle 把 cmd 綁定到 request / response 型別與 handler，middleware 只套用在這個指令 (在 Use 的內層)。
+// 整條 middleware 在這裡包好一次，Dispatch 不用每則訊息重新組合。重複註冊同一個 cmd 會 panic
+func Handle[Req, Resp proto.Message](r *CommandRouter, cmd string, handler func(req *Request, payload Req) (Resp, error), middleware ...Middleware) {
+	if _, ok := r.commands[cmd]; ok {
+		panic("command registered twice: " + cmd)
+	}
+	var zero Req
+	h := Handler(func(req *Request) (proto.Message, error) {
+		return handler(req, req.Payload.(Req))
+	})
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		h = r.middleware[i](h)
+	}
+	r.commands[cmd] = command{
+		newRequest: func() proto.Message { return zero.ProtoReflect().Type().New().Interface() },
+		handler:    h,
+	}
+}
+
+// Dispatch 處理 client 送來的訊息並回傳回應，回應的 cmd 與 req_seq 和請求相同，失敗時帶 error 沒有 payload
+func (r *CommandRouter) Dispatch(client *Client, msg *pb.Message) *pb.Message {
+	resp := &pb.Message{Cmd: msg.Cmd, ReqSeq: msg.ReqSeq}
+
+	cmd, ok := r.commands[msg.Cmd]
+	if !ok {
+		resp.Error = &pb.Error{Code: CodeUnknownCommand, Message: fmt.Sprintf("unknown command %q", msg.Cmd)}
+		return resp
+	}
+	payload := cmd.newRequest()
+	if err := proto.Unmarshal(msg.Payload, payload); err != nil {
+		resp.Error = &pb.Error{Code: CodeInvalidPayload, Message: err.Error()}
+		return resp
+	}
+
+	result, err := cmd.handler(&Request{Client: client, Cmd: msg.Cmd, ReqSeq: msg.ReqSeq, Payload: payload})
+	if err == nil {
+		resp.Payload, err = proto.Marshal(result)
+	}
+	if err != nil {
+		resp.Payload = nil
+		resp.Error = toError(err)
+	}
+	return resp
+}
+
+func toError(err error) *pb.Error {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return &pb.Error{Code: cmdErr.Code, Message: cmdErr.Message}
+	}
+	fmt.Println("command failed:", err)
+	return &pb.Error{Code: CodeInternal, Message: "internal error"}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+func TestDispatch(t *testing.T) {
+	r := NewCommandRouter()
+	Handle(r, "join_room", func(req *Request, payload *pb.JoinRoomReq) (*pb.JoinRoomResp, error) {
+		switch payload.RoomId {
+		case "":
+			return nil, Errorf(CodeInvalidArgument, "room_id is required")
+		case "broken":
+			return nil, errors.New("database is down")
+		}
+		return &pb.JoinRoomResp{Status: "ok", Message: req.Client.ID + " joined " + payload.RoomId}, nil
+	})
+	client := &Client{ID: "c1"}
+
+	tests := []struct {
+		name     string
+		msg      *pb.Message
+		wantCode string
+	}{
+		{"ok", &pb.Message{Cmd: "join_room", Payload: marshal(t, &pb.JoinRoomReq{RoomId: "lobby"}), ReqSeq: 1}, ""},
+		{"unknown command", &pb.Message{Cmd: "start_game", ReqSeq: 2}, CodeUnknownCommand},
+		{"invalid payload", &pb.Message{Cmd: "join_room", Payload: []byte{0xff, 0xff}, ReqSeq: 3}, CodeInvalidPayload},
+		{"handler error", &pb.Message{Cmd: "join_room", ReqSeq: 4}, CodeInvalidArgument},
+		{"internal error is hidden", &pb.Message{Cmd: "join_room", Payload: marshal(t, &pb.JoinRoomReq{RoomId: "broken"}), ReqSeq: 5}, CodeInternal},
+	}
+	for _, tt := range tests {
+		resp := r.Dispatch(client, tt.msg)
+		if resp.Cmd != tt.msg.Cmd || resp.ReqSeq != tt.msg.ReqSeq {
+			t.Errorf("%s: response %v is not correlated with the request", tt.name, resp)
+		}
+		if tt.wantCode == "" {
+			got := &pb.JoinRoomResp{}
+			if err := proto.Unmarshal(resp.Payload, got); err != nil || resp.Error != nil || got.Message != "c1 joined lobby" {
+				t.Errorf("%s: %v", tt.name, resp)
+			}
+			continue
+		}
+		if resp.Error.GetCode() != tt.wantCode || len(resp.Payload) != 0 {
+			t.Errorf("%s: %v, want error %s", tt.name, resp, tt.wantCode)
+		}
+		if tt.wantCode == CodeInternal && strings.Contains(resp.Error.Message, "database") {
+			t.Errorf("%s: internal error leaked: %q", tt.name, resp.Error.Message)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	wraps := 0
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			wraps++
+			return func(req *Request) (proto.Message, error) {
+				order = append(order, name)
+				return next(req)
+			}
+		}
+	}
+
+	r := NewCommandRouter()
+	r.Use(trace("outer"), trace("inner"))
+	Handle(r, "ping", ping, trace("route"))
+	Handle(r, "secret", ping, RequireMember)
+	Handle(r, "limited", ping, RateLimit(0, 2))
+
+	registered := wraps
+	r.Dispatch(&Client{ID: "c1"}, &pb.Message{Cmd: "ping"})
+	if strings.Join(order, ",") != "outer,inner,route" {
+		t.Errorf("middleware order = %v", order)
+	}
+	// middleware 在 Handle 時就包好，Dispatch 不會重新包裝
+	r.Dispatch(&Client{ID: "c1"}, &pb.Message{Cmd: "ping"})
+	if wraps != registered {
+		t.Errorf("middleware wrapped %d more times while dispatching", wraps-registered)
+	}
+
+	if resp := r.Dispatch(&Client{ID: "anonymous"}, &pb.Message{Cmd: "secret"}); resp.Error.GetCode() != CodeUnauthenticated {
+		t.Errorf("anonymous client: %v", resp)
+	}
+	if resp := r.Dispatch(&Client{ID: "member-1", Authenticated: true}, &pb.Message{Cmd: "secret"}); resp.Error != nil {
+		t.Errorf("member: %v", resp)
+	}
+
+	// 每個連線各自計算，burst 用完之後就被擋下
+	a, b := &Client{ID: "a"}, &Client{ID: "b"}
+	for i, want := range []string{"", "", CodeRateLimited} {
+		if resp := r.Dispatch(a, &pb.Message{Cmd: "limited"}); resp.Error.GetCode() != want {
+			t.Errorf("command %d from a: %v, want %q", i+1, resp, want)
+		}
+	}
+	if resp := r.Dispatch(b, &pb.Message{Cmd: "limited"}); resp.Error != nil {
+		t.Errorf("b should have its own limit: %v", resp)
+	}
+}
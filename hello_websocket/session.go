@@ -0,0 +1,55 @@
+package main
+
+import (
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+// Welcome.resume 的值
+const (
+	ResumeResumed = "resumed"
+	ResumeResync  = "resync"
+)
+
+// replayBufferSize 是每個 session 最多保留幾則還沒 ack 的訊息，必須比 sendBufferSize 小，補送時才放得進 Send
+const replayBufferSize = 128
+
+// session 是送給同一個 client 的訊息序列，只在 Hub.Run 裡讀寫。
+// client 重新連線並帶上 resume token 時，session 交給新的 Client 繼續使用
+type session struct {
+	token  string
+	seq    uint64        // 最後一個分配出去的序號
+	buffer []*pb.Message // 還沒 ack 的訊息，依 seq 排列
+}
+
+func newSession() *session {
+	return &session{token: newClientID()}
+}
+
+// push 複製 message 並加上下一個序號放進 buffer，buffer 滿了就丟掉最舊的。
+// 同一則訊息會送給很多 client，所以不能直接修改它
+func (s *session) push(message *pb.Message) *pb.Message {
+	s.seq++
+	message = &pb.Message{Cmd: message.Cmd, Payload: message.Payload, ReqSeq: message.ReqSeq, Error: message.Error, Seq: s.seq}
+	if len(s.buffer) == replayBufferSize {
+		s.buffer = append(s.buffer[:0], s.buffer[1:]...)
+	}
+	s.buffer = append(s.buffer, message)
+	return message
+}
+
+// ack 移除 seq (含) 之前的訊息
+func (s *session) ack(seq uint64) {
+	n := 0
+	for n < len(s.buffer) && s.buffer[n].Seq <= seq {
+		n++
+	}
+	s.buffer = append(s.buffer[:0], s.buffer[n:]...)
+}
+
+// covers 回傳 lastSeq 之後的訊息是否都還在 buffer 裡
+func (s *session) covers(lastSeq uint64) bool {
+	if lastSeq >= s.seq {
+		return lastSeq == s.seq
+	}
+	return len(s.buffer) > 0 && s.buffer[0].Seq <= lastSeq+1
+}
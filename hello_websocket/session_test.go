@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	pb "hello_world/hello_websocket/proto/go"
+)
+
+// startResumable 啟動 server，members 為 true 時以 X-Member-Id 驗證，否則都是匿名連線
+func startResumable(t *testing.T, window time.Duration, members bool) (*Hub, *httptest.Server) {
+	t.Helper()
+	hub := NewHub()
+	hub.ResumeWindow = window
+	if members {
+		hub.Authenticate = headerAuthenticator("X-Member-Id")
+	}
+	return hub, startServer(t, hub)
+}
+
+// connect 以 member 連線，session 不為 nil 時帶上 resume token 與 lastSeq
+func connect(t *testing.T, srv *httptest.Server, member string, session *pb.Welcome, lastSeq uint64) (*websocket.Conn, *pb.Welcome) {
+	t.Helper()
+	path := "/ws"
+	if session != nil {
+		path = fmt.Sprintf("/ws?resume_token=%s&last_seq=%d", session.ResumeToken, lastSeq)
+	}
+	conn := dialURL(t, srv, path, "", http.Header{"X-Member-Id": {member}})
+	return conn, readSession(t, conn)
+}
+
+// waitDetached 等到 hub 發現 client 斷線
+func waitDetached(t *testing.T, hub *Hub, id string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		detached := false
+		hub.do(func() { detached = hub.Clients[id] != nil && hub.Clients[id].detached })
+		if detached {
+			return
+		}
+	}
+	t.Fatalf("%s is still connected", id)
+}
+
+func TestSequenceAndAck(t *testing.T) {
+	hub, srv := startResumable(t, time.Minute, true)
+	conn, session := connect(t, srv, "alice", nil, 0)
+	if session.ResumeToken == "" || session.Resume != "" {
+		t.Fatalf("welcome = %v, want a new session", session)
+	}
+
+	// 回應與推送共用同一個序列，從 1 開始
+	for want := uint64(1); want <= 2; want++ {
+		send(t, conn, &pb.Message{Cmd: "ping", ReqSeq: want})
+		if _, msg := read(t, conn); msg.Seq != want {
+			t.Errorf("response %v, want seq %d", msg, want)
+		}
+	}
+
+	// ack 沒有回應，下一則訊息就是 ping 的回應
+	send(t, conn, &pb.Message{Cmd: "ack", Ack: 2})
+	send(t, conn, &pb.Message{Cmd: "ping", ReqSeq: 3})
+	if _, msg := read(t, conn); msg.Cmd != "ping" || msg.Seq != 3 {
+		t.Errorf("got %v, want the ping response with seq 3", msg)
+	}
+
+	var buffered []uint64
+	hub.do(func() {
+		for _, msg := range hub.Clients["alice"].session.buffer {
+			buffered = append(buffered, msg.Seq)
+		}
+	})
+	if !slices.Equal(buffered, []uint64{3}) {
+		t.Errorf("replay buffer = %v, want only the unacknowledged message", buffered)
+	}
+}
+
+func TestResume(t *testing.T) {
+	hub, srv := startResumable(t, time.Minute, true)
+	alice, session := connect(t, srv, "alice", nil, 0)
+	bob, _ := connect(t, srv, "bob", nil, 0)
+	for _, conn := range []*websocket.Conn{alice, bob} {
+		call(t, conn, "join_room", &pb.JoinRoomReq{RoomId: "lobby"}, &pb.JoinRoomResp{})
+	}
+	// alice 收到 join_room 的回應 (seq 1) 之後斷線，期間仍在房間裡
+	alice.Close()
+	waitDetached(t, hub, "alice")
+	sent := &pb.RoomMessageResp{}
+	call(t, bob, "room_message", &pb.RoomMessageReq{RoomId: "lobby", Data: []byte("missed")}, sent)
+	if sent.Delivered != 1 {
+		t.Errorf("delivered = %d, alice's session should still be in the room", sent.Delivered)
+	}
+
+	alice, resumed := connect(t, srv, "alice", session, 1)
+	if resumed.Resume != ResumeResumed || resumed.ResumeToken != session.ResumeToken {
+		t.Fatalf("welcome = %v, want the session resumed", resumed)
+	}
+	_, msg := read(t, alice)
+	if msg.Cmd != "room_message" || msg.Seq != 2 {
+		t.Fatalf("replayed %v, want the room message with seq 2", msg)
+	}
+	send(t, alice, &pb.Message{Cmd: "ping", ReqSeq: 1})
+	if _, msg := read(t, alice); msg.Seq != 3 {
+		t.Errorf("got %v, want the sequence to continue", msg)
+	}
+	call(t, alice, "room_message", &pb.RoomMessageReq{RoomId: "lobby", Data: []byte("back")}, sent)
+	if chat := readChat(t, bob, "room_message"); string(chat.Data) != "back" {
+		t.Errorf("bob got %v", chat)
+	}
+
+	// 匿名連線 resume 後沿用原本的 ID
+	hub, srv = startResumable(t, time.Minute, false)
+	anonymous, session := connect(t, srv, "", nil, 0)
+	anonymous.Close()
+	waitDetached(t, hub, session.ClientId)
+	if _, resumed := connect(t, srv, "", session, 0); resumed.Resume != ResumeResumed || resumed.ClientId != session.ClientId {
+		t.Errorf("welcome = %v, want %s resumed", resumed, session.ClientId)
+	}
+}
+
+func TestReplacedConnection(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	old := &Client{ID: "alice", Authenticated: true, Send: make(chan *pb.Message, 8), Hub: hub}
+	hub.Register <- old
+	hub.JoinRoom(old, "lobby")
+	var token string
+	hub.do(func() { token = old.session.token })
+
+	resumed := &Client{ID: "alice", Authenticated: true, Send: make(chan *pb.Message, 8), Hub: hub, resumeToken: token}
+	hub.Register <- resumed
+	if !hub.InRoom(resumed, "lobby") {
+		t.Fatal("the resumed connection should keep the rooms")
+	}
+
+	// 舊連線還在處理中的指令不能影響新的連線
+	if hub.InRoom(old, "lobby") {
+		t.Error("the replaced connection should not be in the room")
+	}
+	if hub.LeaveRoom(old, "lobby") {
+		t.Error("the replaced connection should not be able to leave the room")
+	}
+	if !hub.InRoom(resumed, "lobby") {
+		t.Error("the resumed connection was removed from the room")
+	}
+	if members, _ := hub.RoomMembers("lobby"); !slices.Equal(members, []string{"alice"}) {
+		t.Errorf("members = %v", members)
+	}
+}
+
+func TestResync(t *testing.T) {
+	hub, srv := startResumable(t, time.Minute, true)
+	alice, session := connect(t, srv, "alice", nil, 0)
+	bob, _ := connect(t, srv, "bob", nil, 0)
+	call(t, alice, "join_room", &pb.JoinRoomReq{RoomId: "lobby"}, &pb.JoinRoomResp{})
+
+	if _, got := connect(t, srv, "carol", &pb.Welcome{ResumeToken: "unknown"}, 0); got.Resume != ResumeResync {
+		t.Errorf("unknown token: welcome = %v, want resync", got)
+	}
+	if _, got := connect(t, srv, "bob", session, 1); got.Resume != ResumeResync {
+		t.Errorf("another member's token: welcome = %v, want resync", got)
+	}
+	bob.Close()
+
+	// 斷線期間的訊息超過 replay buffer，缺少的訊息補不回來
+	alice.Close()
+	waitDetached(t, hub, "alice")
+	for i := 0; i <= replayBufferSize; i++ {
+		hub.SendToMember("alice", &pb.Message{Cmd: "notice"})
+	}
+	alice, got := connect(t, srv, "alice", session, 1)
+	if got.Resume != ResumeResync || got.ResumeToken == session.ResumeToken {
+		t.Fatalf("welcome = %v, want resync with a new session", got)
+	}
+	// 新的 session 從 1 開始，也不在之前的房間裡
+	if members := members(t, alice, "lobby"); len(members) != 0 {
+		t.Errorf("members = %v, want an empty room", members)
+	}
+	send(t, alice, &pb.Message{Cmd: "ping"})
+	if _, msg := read(t, alice); msg.Seq != 2 {
+		t.Errorf("got %v, want seq 2 after room_members", msg)
+	}
+}
+
+func TestResumeWindowExpires(t *testing.T) {
+	_, srv := startResumable(t, 50*time.Millisecond, true)
+	alice, session := connect(t, srv, "alice", nil, 0)
+	bob, _ := connect(t, srv, "bob", nil, 0)
+	for _, conn := range []*websocket.Conn{alice, bob} {
+		call(t, conn, "join_room", &pb.JoinRoomReq{RoomId: "lobby"}, &pb.JoinRoomResp{})
+	}
+
+	alice.Close()
+	waitMembers(t, bob, "lobby", "bob")
+	if _, got := connect(t, srv, "alice", session, 1); got.Resume != ResumeResync {
+		t.Errorf("welcome = %v, want resync after the window", got)
+	}
+}